@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"temporal-version-exporter/scraper"
+)
+
+// checkOptions are the flags of the check command.
+type checkOptions struct {
+	url     string
+	readyz  bool
+	timeout time.Duration
+}
+
+func newCheckOptions(fs *flag.FlagSet) *checkOptions {
+	o := &checkOptions{}
+	fs.StringVar(&o.url, "url", "", "check: URL to check (default http or https://localhost:<port of --listen-addr>/healthz)")
+	fs.BoolVar(&o.readyz, "readyz", false, "check: check /readyz instead of /healthz, for startup and readiness probes")
+	fs.DurationVar(&o.timeout, "timeout", 3*time.Second, "check: time allowed for the request")
+	return o
+}
+
+// runCheck implements the check command: it sends a GET to the exporter's
+// /healthz, or /readyz with --readyz, and returns 0 if the answer is 2xx
+// and 1 otherwise. It prints nothing on success, so that it can serve as a
+// container HEALTHCHECK in images without curl. Without --url it checks
+// the exporter that c configures, over https if c enables web TLS.
+func runCheck(ctx context.Context, c *config, o *checkOptions) int {
+	url, client := o.url, http.DefaultClient
+	if url == "" {
+		path := "/healthz"
+		if o.readyz {
+			path = "/readyz"
+		}
+		scheme := "http"
+		if c.webTLSCertFile != "" || scraper.WebConfigTLS(c.webConfigFile) {
+			// The certificate is issued for the exporter's public name,
+			// not localhost, and only liveness is checked here.
+			scheme = "https"
+			client = &http.Client{Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			}}
+		}
+		u, err := localURL(scheme, c.listenAddr, path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "check:", err)
+			return 1
+		}
+		url = u
+	} else if c.flagSet("readyz") {
+		fmt.Fprintln(os.Stderr, "check: --readyz cannot be combined with --url")
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "check:", err)
+		return 1
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "check:", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		fmt.Fprintf(os.Stderr, "check: %s returned %s: %s\n", url, resp.Status, body)
+		return 1
+	}
+	return 0
+}
+
+// localURL returns the scheme URL of path on the exporter listening on
+// listenAddr, reached through localhost when it listens on all addresses.
+func localURL(scheme, listenAddr, path string) (string, error) {
+	host, port, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return "", fmt.Errorf("deriving the URL from --listen-addr: %w", err)
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port) + path, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"temporal-version-exporter/scraper"
+)
+
+// commands are the subcommands of the exporter binary. Each registers its
+// own flags, if it has any, on the FlagSet of the shared ones and returns
+// the function that runs it with the parsed config and returns the exit
+// code.
+var commands = map[string]func(fs *flag.FlagSet) func(ctx context.Context, c *config) int{
+	"serve": func(*flag.FlagSet) func(context.Context, *config) int {
+		return func(ctx context.Context, c *config) int {
+			if err := runServe(ctx, c); err != nil {
+				fatal("scraper failed", "err", err)
+			}
+			return 0
+		}
+	},
+	"once": func(*flag.FlagSet) func(context.Context, *config) int {
+		return runOnceCommand
+	},
+	"check": func(fs *flag.FlagSet) func(context.Context, *config) int {
+		opts := newCheckOptions(fs)
+		return func(ctx context.Context, c *config) int { return runCheck(ctx, c, opts) }
+	},
+	"version": func(*flag.FlagSet) func(context.Context, *config) int {
+		return func(context.Context, *config) int {
+			fmt.Println(scraper.VersionString())
+			return 0
+		}
+	},
+}
+
+// usage returns the usage message of fs, which holds the shared flags and
+// those of the command being parsed.
+func usage(fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintf(fs.Output(), `Usage: %s [flags] [command] [flags]
+
+Commands:
+  serve    export the versions over HTTP until stopped (the default)
+  once     refresh every target once and print address=version lines
+  check    check /healthz or /readyz of a running exporter
+  version  print version information
+
+Flags, which every command shares, may come before or after the command;
+those of check only after it:
+`, fs.Name())
+		fs.PrintDefaults()
+	}
+}
+
+// execute parses args, the command-line arguments without the program
+// name, into the config and runs the command they name, serve if none,
+// returning the exit code. Invalid flags exit as flag.ExitOnError does.
+func execute(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.Usage = usage(fs)
+	c := newConfig(fs)
+	fs.Parse(args) // exits on error
+
+	name, rest := "serve", fs.Args()
+	if len(rest) > 0 {
+		name, rest = rest[0], rest[1:]
+	}
+	setup, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(fs.Output(), "unknown command %q\n", name)
+		fs.Usage()
+		return 1
+	}
+	run := setup(fs)
+	// The flags may also follow the command name.
+	fs.Parse(rest)
+	if fs.NArg() > 0 {
+		fatal("invalid arguments", "err", fmt.Sprintf("unexpected arguments after %s: %q", name, fs.Args()))
+	}
+	return run(ctx, c)
+}
+
+// runOnceCommand implements the once command and --once.
+func runOnceCommand(ctx context.Context, c *config) int {
+	s := c.newScraper()
+	defer s.Stop()
+	return runOnce(ctx, s, os.Stdout)
+}
+
+// runOnce refreshes every target of s once, writes an address=version line
+// for each to w and returns the exit code: 0 if every version was found, 2
+// if any is unknown or no target was discovered and 1 on errors.
+func runOnce(ctx context.Context, s *scraper.Scraper, w io.Writer) int {
+	results, err := s.RefreshOnce(ctx)
+	if err != nil {
+		slog.Error("refresh failed", "err", err)
+		return 1
+	}
+	if len(results) == 0 {
+		slog.Warn("no targets discovered")
+		return 2
+	}
+	code := 0
+	for _, res := range results {
+		version := res.Version
+		if version == "" {
+			version, code = "unknown", 2
+		}
+		fmt.Fprintf(w, "%s=%s\n", res.Address, version)
+	}
+	return code
+}
+
+// fatal logs msg at error level and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
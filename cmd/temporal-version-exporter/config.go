@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"net/url"
+	"os"
+	"strings"
+
+	"temporal-version-exporter/scraper"
+)
+
+// envFlags maps the flags whose default is read from an environment
+// variable to that variable.
+var envFlags = map[string]string{
+	"api-key":               "TEMPORAL_API_KEY",
+	"temporal-addr":         "TEMPORAL_ADDR",
+	"listen-addr":           "LISTEN_ADDR",
+	"scrape-interval":       "SCRAPE_INTERVAL",
+	"pagerduty-routing-key": "PAGERDUTY_ROUTING_KEY",
+	"redis-password":        "REDIS_PASSWORD",
+	"webhook-secret":        "WEBHOOK_SECRET",
+	"metrics-password":      "METRICS_PASSWORD",
+}
+
+// secretFlags are the flags whose values /config does not show.
+var secretFlags = map[string]bool{
+	"api-key":               true,
+	"pagerduty-routing-key": true,
+	"redis-password":        true,
+	"webhook-secret":        true,
+	"remote-write-headers":  true,
+	"metrics-password":      true,
+}
+
+// urlFlags are the flags whose values are URLs that may carry credentials,
+// in the user info or, as with Slack webhooks, in the path. /config only
+// shows their scheme and host.
+var urlFlags = map[string]bool{
+	"webhook-url":      true,
+	"remote-write-url": true,
+}
+
+const redacted = "<redacted>"
+
+// redactURL returns the scheme and host of raw, with anything after them
+// replaced by redacted. A value that does not parse is redacted entirely.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return redacted
+	}
+	if u.User == nil && strings.Trim(u.Path, "/") == "" && u.RawQuery == "" && u.Fragment == "" {
+		return u.Scheme + "://" + u.Host
+	}
+	return u.Scheme + "://" + u.Host + "/" + redacted
+}
+
+// settings returns the value and source of every flag, with secrets
+// redacted, for /config.
+func (c *config) settings() map[string]scraper.Setting {
+	set := map[string]bool{}
+	c.fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	values := map[string]scraper.Setting{}
+	c.fs.VisitAll(func(f *flag.Flag) {
+		v := scraper.Setting{Value: f.Value.String(), Source: "default"}
+		switch env := envFlags[f.Name]; {
+		case set[f.Name]:
+			v.Source = "flag"
+		case env != "" && os.Getenv(env) != "":
+			v.Source, v.Env = "env", env
+		}
+		switch {
+		case v.Value == "":
+		case secretFlags[f.Name]:
+			v.Value = redacted
+		case urlFlags[f.Name]:
+			v.Value = redactURL(v.Value)
+		}
+		values[f.Name] = v
+	})
+	return values
+}
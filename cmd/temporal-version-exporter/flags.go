@@ -0,0 +1,309 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// config is the configuration of every command, built once from the
+// command-line flags and the environment by execute.
+type config struct {
+	temporalAddr  string
+	listenAddr    string
+	metricsPath   string
+	scrapeInt     time.Duration
+	showVersion   bool
+	noGoMetrics   bool
+	goCollector   bool
+	procCollector bool
+	labelsFromEnv envLabelsFlag
+	constLabels   constLabelsFlag
+	extraLabels   extraLabelsFlag
+	metricPrefix  string
+
+	useTLS         bool
+	apiKey         string
+	tlsSkipVerify  bool
+	tlsCAFile      string
+	tlsCertFile    string
+	tlsKeyFile     string
+	dialTimeout    time.Duration
+	requestTimeout time.Duration
+
+	maxRetries int
+
+	grpcInterceptors string
+
+	dnsRefreshInterval time.Duration
+
+	k8sServiceSelector string
+	kubeconfig         string
+
+	adaptiveThreshold   int
+	adaptiveMaxInterval time.Duration
+
+	sysInfoRecheck time.Duration
+
+	enableHealthProbe bool
+
+	supportedClientsFilter string
+
+	staleHandling  string
+	staleDropAfter int
+
+	versionHistorySize int
+
+	minVersion        string
+	expectedVersion   string
+	versionConstraint string
+	targetsFile       string
+
+	supportWindow int
+
+	latestCheckInterval time.Duration
+	offline             bool
+
+	webhookURL     string
+	webhookTimeout time.Duration
+	webhookRetries int
+	webhookSecret  string
+
+	pagerDutyRoutingKey string
+	pagerDutyThreshold  int
+
+	redisAddr     string
+	redisPassword string
+	redisDB       int
+
+	auditLogPath      string
+	auditLogMaxSizeMB int
+
+	textfileOutput string
+
+	remoteWriteURL     string
+	remoteWriteTimeout time.Duration
+	remoteWriteHeaders headersFlag
+
+	webReadTimeout       time.Duration
+	webReadHeaderTimeout time.Duration
+	webWriteTimeout      time.Duration
+	webIdleTimeout       time.Duration
+	webMaxHeaderBytes    int
+	disableHTTP          bool
+	shutdownGracePeriod  time.Duration
+
+	webConfigFile string
+
+	webTLSCertFile   string
+	webTLSKeyFile    string
+	webTLSMinVersion string
+
+	basicAuthUsersFile string
+	metricsUsername    string
+	metricsPassword    string
+
+	webAllowedCIDRs     string
+	webTrustProxy       bool
+	webAllowlistHealthz bool
+
+	webAccessLog              bool
+	webAccessLogExcludeProbes bool
+
+	metricsMaxRequests int
+	metricsTimeout     time.Duration
+
+	metricsRateLimit float64
+
+	enablePprof     bool
+	pprofListenAddr string
+
+	readyRequires string
+	readyStrict   bool
+
+	once bool
+
+	generateDashboard bool
+
+	generateRules bool
+
+	// fs holds the flags the config was parsed from.
+	fs *flag.FlagSet
+}
+
+// newConfig registers the flags shared by the commands on fs, with their
+// defaults taken from the environment where one applies, and returns the
+// config they are parsed into.
+func newConfig(fs *flag.FlagSet) *config {
+	c := &config{
+		labelsFromEnv:      envLabelsFlag{},
+		constLabels:        constLabelsFlag{},
+		extraLabels:        extraLabelsFlag{},
+		remoteWriteHeaders: headersFlag{},
+		fs:                 fs,
+	}
+	fs.StringVar(&c.temporalAddr, "temporal-addr", getEnv("TEMPORAL_ADDR", "127.0.0.1:7236"), "Temporal frontend gRPC address")
+	fs.StringVar(&c.listenAddr, "listen-addr", getEnv("LISTEN_ADDR", ":9090"), "metrics listen address")
+	fs.StringVar(&c.metricsPath, "metrics-path", "/metrics", "path under which to expose metrics")
+	fs.DurationVar(&c.scrapeInt, "scrape-interval", getEnvDuration("SCRAPE_INTERVAL", 30*time.Second), "how often to refresh version")
+	fs.BoolVar(&c.showVersion, "version", false, "print exporter version information and exit")
+	fs.BoolVar(&c.noGoMetrics, "disable-go-metrics", false, "do not export Go runtime and process metrics (overrides the two flags below)")
+	fs.BoolVar(&c.goCollector, "enable-go-collector", true, "export go_* runtime metrics")
+	fs.BoolVar(&c.procCollector, "enable-process-collector", true, "export process_* metrics")
+	fs.StringVar(&c.metricPrefix, "metric-prefix", "temporal", "prefix for all exporter metric names, joined to the rest of the name with '_'")
+	fs.BoolVar(&c.useTLS, "tls", false, "connect to the Temporal frontend over TLS (enabled automatically for Temporal Cloud addresses)")
+	fs.StringVar(&c.apiKey, "api-key", getEnv("TEMPORAL_API_KEY", ""), "API key sent as a bearer token, required for Temporal Cloud")
+	fs.BoolVar(&c.tlsSkipVerify, "tls-insecure-skip-verify", false, "do not verify the frontend's TLS certificate")
+	fs.StringVar(&c.tlsCAFile, "tls-ca-file", "", "PEM CA certificate to verify the frontend with instead of the system roots (implies --tls)")
+	fs.StringVar(&c.tlsCertFile, "tls-cert-file", "", "PEM client certificate to present to the frontend, for mTLS (implies --tls)")
+	fs.StringVar(&c.tlsKeyFile, "tls-key-file", "", "PEM private key of --tls-cert-file")
+	fs.DurationVar(&c.dialTimeout, "grpc-dial-timeout", 10*time.Second, "timeout for establishing the gRPC connection, including name resolution")
+	fs.DurationVar(&c.requestTimeout, "grpc-request-timeout", 5*time.Second, "timeout for each RPC attempt")
+	fs.IntVar(&c.maxRetries, "max-retries", 3, "retries per RPC for transient gRPC errors (Unavailable, DeadlineExceeded, ResourceExhausted)")
+	fs.StringVar(&c.grpcInterceptors, "grpc-interceptors", "retry,metrics", "comma-separated gRPC client interceptors, outermost first: retry, logging, metrics; empty disables all")
+	fs.DurationVar(&c.dnsRefreshInterval, "dns-refresh-interval", 60*time.Second, "how often to re-resolve an SRV record given as --temporal-addr")
+	fs.StringVar(&c.k8sServiceSelector, "kubernetes-service-selector", "", "discover targets from Kubernetes Services matching this label selector, e.g. app=temporal-frontend (replaces --temporal-addr)")
+	fs.StringVar(&c.kubeconfig, "kubeconfig", "", "kubeconfig used for Kubernetes discovery; the in-cluster service account is used when empty")
+	fs.IntVar(&c.adaptiveThreshold, "adaptive-backoff-threshold", 3, "consecutive failed refreshes of a target after which its scrape interval is doubled with every further failure; 0 disables")
+	fs.DurationVar(&c.adaptiveMaxInterval, "adaptive-max-interval", 0, "upper bound of a backed-off scrape interval (default 10 * --scrape-interval)")
+	fs.DurationVar(&c.sysInfoRecheck, "system-info-recheck-interval", time.Hour, "how long to skip GetSystemInfo on a target that answered Unimplemented before trying it again")
+	fs.BoolVar(&c.enableHealthProbe, "enable-health-probe", false, "call the gRPC health service of the frontend every cycle and export temporal_frontend_healthy")
+	fs.StringVar(&c.supportedClientsFilter, "supported-clients-filter", "", "comma-separated client names (e.g. temporal-go,temporal-java) to export in temporal_cluster_supported_client_info; all clients when empty")
+	fs.StringVar(&c.staleHandling, "stale-handling", "keep", "what to do with the last-known version while a target is failing: keep, mark (also set temporal_server_version_stale) or drop (delete it after --stale-drop-after failures)")
+	fs.IntVar(&c.staleDropAfter, "stale-drop-after", 3, "consecutive failed refreshes after which --stale-handling=drop deletes the version series")
+	fs.IntVar(&c.versionHistorySize, "version-history-size", 20, "number of versions remembered per target for /version-history")
+	fs.StringVar(&c.minVersion, "min-version", "", "lowest acceptable server version (semver, e.g. 1.22.0); exports temporal_server_version_below_minimum when set")
+	fs.StringVar(&c.expectedVersion, "expected-version", "", "version every target should run (semver, e.g. 1.24.2); exports temporal_server_version_mismatch when set")
+	fs.StringVar(&c.versionConstraint, "version-constraint", "", "semver range every target's version should satisfy, e.g. '>=1.23.0 <1.25.0' or '~1.22'; exports temporal_server_version_constraint_satisfied when set")
+	fs.StringVar(&c.targetsFile, "targets-file", "", "YAML file of per-target settings, expected_version and version_constraint, that replace the global ones; re-read on SIGHUP")
+	fs.IntVar(&c.supportWindow, "support-window-minors", 3, "number of most recent minor releases in the embedded release table that count as supported")
+	fs.DurationVar(&c.latestCheckInterval, "latest-version-check-interval", 0, "how often to look up the latest Temporal release on GitHub; disabled when 0")
+	fs.BoolVar(&c.offline, "offline", false, "make no outbound calls other than to Temporal: disables the latest release check, webhooks, PagerDuty, Redis and remote write")
+	fs.StringVar(&c.webhookURL, "webhook-url", "", "URL to POST a JSON event to whenever a target's version changes")
+	fs.DurationVar(&c.webhookTimeout, "webhook-timeout", 5*time.Second, "timeout for each webhook request")
+	fs.IntVar(&c.webhookRetries, "webhook-retries", 3, "retries for a failed webhook request")
+	fs.StringVar(&c.webhookSecret, "webhook-secret", getEnv("WEBHOOK_SECRET", ""), "if set, sign webhook bodies with HMAC-SHA256 in the X-Temporal-Signature header")
+	fs.StringVar(&c.pagerDutyRoutingKey, "pagerduty-routing-key", getEnv("PAGERDUTY_ROUTING_KEY", ""), "PagerDuty Events API v2 routing key; when set, an alert is triggered for a target whose version stays unknown")
+	fs.IntVar(&c.pagerDutyThreshold, "pagerduty-failure-threshold", 3, "consecutive failed refreshes after which a PagerDuty alert is triggered")
+	fs.StringVar(&c.redisAddr, "redis-addr", "", "Redis address (host:port) used to remember detected versions across restarts; disabled when empty")
+	fs.StringVar(&c.redisPassword, "redis-password", getEnv("REDIS_PASSWORD", ""), "Redis password")
+	fs.IntVar(&c.redisDB, "redis-db", 0, "Redis database number")
+	fs.StringVar(&c.auditLogPath, "audit-log-path", "", "append a JSON line to this file for every detected version change; disabled when empty")
+	fs.IntVar(&c.auditLogMaxSizeMB, "audit-log-max-size-mb", 100, "size in megabytes at which the audit log is rotated")
+	fs.StringVar(&c.textfileOutput, "textfile-output", "", "write all metrics in the text exposition format to this file after every refresh, for node_exporter's textfile collector; the file is replaced atomically")
+	fs.StringVar(&c.remoteWriteURL, "remote-write-url", "", "Prometheus remote write endpoint to push all metrics to every --scrape-interval; disabled when empty")
+	fs.DurationVar(&c.remoteWriteTimeout, "remote-write-timeout", 10*time.Second, "timeout for each remote write request")
+	fs.DurationVar(&c.webReadTimeout, "web-read-timeout", 5*time.Second, "time allowed to read an HTTP request, headers and body; 0 disables")
+	fs.DurationVar(&c.webReadHeaderTimeout, "web-read-header-timeout", 5*time.Second, "time allowed to read the headers of an HTTP request; 0 disables")
+	fs.DurationVar(&c.webWriteTimeout, "web-write-timeout", 10*time.Second, "time allowed to read an HTTP request and write its response; 0 disables")
+	fs.DurationVar(&c.webIdleTimeout, "web-idle-timeout", 2*time.Minute, "time an idle keep-alive HTTP connection is kept open; 0 disables")
+	fs.IntVar(&c.webMaxHeaderBytes, "web-max-header-bytes", 64<<10, "maximum size of the headers of an HTTP request")
+	fs.BoolVar(&c.disableHTTP, "disable-http", false, "do not listen for HTTP at all; the metrics are only exported through --textfile-output or --remote-write-url")
+	fs.DurationVar(&c.shutdownGracePeriod, "shutdown-grace-period", 10*time.Second, "time HTTP requests in progress get to complete on SIGTERM or SIGINT")
+	fs.StringVar(&c.webConfigFile, "web.config.file", "", "exporter-toolkit web configuration file enabling TLS, mTLS client verification and basic auth on every endpoint (replaces the --web-tls-* and --web-basic-auth-users-file flags)")
+	fs.StringVar(&c.webTLSCertFile, "web-tls-cert-file", "", "serve HTTPS with this PEM certificate; reloaded when the file changes (requires --web-tls-key-file)")
+	fs.StringVar(&c.webTLSKeyFile, "web-tls-key-file", "", "PEM private key for --web-tls-cert-file")
+	fs.StringVar(&c.webTLSMinVersion, "server-tls-min-version", "TLS12", "lowest TLS version the HTTPS server accepts: TLS10, TLS11, TLS12 or TLS13")
+	fs.StringVar(&c.basicAuthUsersFile, "web-basic-auth-users-file", "", "require HTTP basic auth on every endpoint but /healthz, checked against this file of username:bcrypt-hash lines; re-read on SIGHUP")
+	fs.StringVar(&c.metricsUsername, "metrics-username", "", "require HTTP basic auth with this username on the metrics endpoint (requires --metrics-password)")
+	fs.StringVar(&c.metricsPassword, "metrics-password", getEnv("METRICS_PASSWORD", ""), "password for --metrics-username")
+	fs.StringVar(&c.webAllowedCIDRs, "web-allowed-cidrs", "", "comma-separated CIDRs, IPv4 or IPv6, allowed to reach the HTTP endpoints; others get 403 (default allows all)")
+	fs.BoolVar(&c.webTrustProxy, "web-trust-proxy", false, "take the client address for --web-allowed-cidrs from the last X-Forwarded-For entry instead of the TCP peer")
+	fs.BoolVar(&c.webAllowlistHealthz, "web-allowed-cidrs-include-healthz", false, "apply --web-allowed-cidrs to /healthz too, which is exempt by default so kubelet probes keep working")
+	fs.BoolVar(&c.webAccessLog, "web-access-log", false, "log one line per HTTP request with its method, path, client, status, size and duration")
+	fs.BoolVar(&c.webAccessLogExcludeProbes, "web-access-log-exclude-probes", false, "leave requests to /healthz and /readyz out of --web-access-log")
+	fs.IntVar(&c.metricsMaxRequests, "web-max-requests", 5, "maximum number of concurrent metrics requests; more are answered with 503. 0 disables the limit")
+	fs.DurationVar(&c.metricsTimeout, "web-metrics-timeout", 10*time.Second, "time after which a metrics request that is still gathering is answered with 503; 0 disables")
+	fs.Float64Var(&c.metricsRateLimit, "metrics-rate-limit", 0, "maximum metrics requests per second, with bursts of 3; more are answered with 429. 0 disables the limit")
+	fs.BoolVar(&c.enablePprof, "enable-pprof", false, "serve net/http/pprof profiles under /debug/pprof/")
+	fs.StringVar(&c.pprofListenAddr, "pprof-listen-addr", "", "serve the --enable-pprof profiles on this address instead of alongside the metrics")
+	fs.StringVar(&c.readyRequires, "ready-requires", "any", "targets that must have completed a successful refresh before /readyz reports ready: any or all")
+	fs.BoolVar(&c.readyStrict, "ready-strict", false, "make /readyz count only targets whose latest refresh succeeded, so readiness is lost again while they fail")
+	fs.BoolVar(&c.once, "once", false, "run the once command instead of serving: refresh every target once, print address=version lines and exit 0 if all versions were found, 2 if any is unknown, 1 on errors")
+	fs.BoolVar(&c.generateDashboard, "generate-dashboard", false, "write a Grafana dashboard for the exporter's metrics to stdout and exit")
+	fs.BoolVar(&c.generateRules, "generate-rules", false, "write Prometheus alerting rules for the exporter's metrics to stdout and exit")
+	fs.Var(c.labelsFromEnv, "label-from-env", "add a constant label to all exporter metrics taken from an environment variable, as LABEL=ENV_VAR (repeatable)")
+	fs.Var(c.constLabels, "const-labels", "comma-separated key=value constant labels added to all exporter metrics")
+	fs.Var(c.extraLabels, "extra-label", "add a static key=value constant label to all exporter metrics (repeatable)")
+	fs.Var(c.remoteWriteHeaders, "remote-write-headers", "HTTP header sent with remote write requests, as 'Name: value' (repeatable)")
+	fs.DurationVar(&c.webReadTimeout, "http-read-timeout", c.webReadTimeout, "alias of --web-read-timeout")
+	fs.DurationVar(&c.webWriteTimeout, "http-write-timeout", c.webWriteTimeout, "alias of --web-write-timeout")
+	fs.DurationVar(&c.webIdleTimeout, "http-idle-timeout", c.webIdleTimeout, "alias of --web-idle-timeout")
+	fs.StringVar(&c.webTLSCertFile, "server-tls-cert", "", "alias of --web-tls-cert-file")
+	fs.StringVar(&c.webTLSKeyFile, "server-tls-key", "", "alias of --web-tls-key-file")
+	return c
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+// flagSet reports whether the named flag was given on the command line.
+func (c *config) flagSet(name string) bool {
+	return flagSetIn(c.fs, name)
+}
+
+// flagSetIn reports whether the named flag of fs was given.
+func flagSetIn(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// splitList splits a comma-separated flag value into its trimmed entries,
+// none if it is empty.
+func splitList(v string) []string {
+	if strings.TrimSpace(v) == "" {
+		return nil
+	}
+	entries := strings.Split(v, ",")
+	for i, e := range entries {
+		entries[i] = strings.TrimSpace(e)
+	}
+	return entries
+}
+
+// headersFlag collects repeated "Name: value" headers.
+type headersFlag http.Header
+
+func (f headersFlag) String() string {
+	var parts []string
+	for name, values := range f {
+		for _, v := range values {
+			parts = append(parts, name+": "+v)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+func (f headersFlag) Set(v string) error {
+	name, value, ok := strings.Cut(v, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("expected 'Name: value', got %q", v)
+	}
+	http.Header(f).Add(name, strings.TrimSpace(value))
+	return nil
+}
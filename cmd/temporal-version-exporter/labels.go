@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"temporal-version-exporter/scraper"
+)
+
+// addLabel validates the value of a static name/value pair and stores it
+// in m. The name is validated at startup by mergeConstLabels.
+func addLabel(m map[string]string, name, value string) error {
+	if !utf8.ValidString(value) {
+		return fmt.Errorf("label %q: value is not valid UTF-8", name)
+	}
+	if _, dup := m[name]; dup {
+		return fmt.Errorf("label %q given more than once", name)
+	}
+	m[name] = value
+	return nil
+}
+
+func formatLabels(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// envLabelsFlag collects repeated --label-from-env=LABEL=ENV_VAR values.
+type envLabelsFlag map[string]string
+
+func (f envLabelsFlag) String() string { return formatLabels(f) }
+
+func (f envLabelsFlag) Set(v string) error {
+	label, env, ok := strings.Cut(v, "=")
+	if !ok || label == "" || env == "" {
+		return fmt.Errorf("expected LABEL=ENV_VAR, got %q", v)
+	}
+	if _, dup := f[label]; dup {
+		return fmt.Errorf("label %q given more than once", label)
+	}
+	f[label] = env
+	return nil
+}
+
+// resolve looks up each environment variable. Unset variables are logged
+// and yield an empty label value rather than failing startup.
+func (f envLabelsFlag) resolve() prometheus.Labels {
+	labels := prometheus.Labels{}
+	for label, env := range f {
+		v, ok := os.LookupEnv(env)
+		if !ok {
+			slog.Warn("environment variable for label is not set, using empty value", "label", label, "env", env)
+		}
+		labels[label] = v
+	}
+	return labels
+}
+
+// constLabelsFlag parses --const-labels=key=value,key=value.
+type constLabelsFlag map[string]string
+
+func (f constLabelsFlag) String() string { return formatLabels(f) }
+
+func (f constLabelsFlag) Set(v string) error {
+	for _, pair := range strings.Split(v, ",") {
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value, got %q", pair)
+		}
+		if err := addLabel(f, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extraLabelsFlag collects repeated --extra-label=key=value values. Unlike
+// --const-labels the value may contain commas.
+type extraLabelsFlag map[string]string
+
+func (f extraLabelsFlag) String() string { return formatLabels(f) }
+
+func (f extraLabelsFlag) Set(v string) error {
+	name, value, ok := strings.Cut(v, "=")
+	if !ok {
+		return fmt.Errorf("expected key=value, got %q", v)
+	}
+	return addLabel(f, name, value)
+}
+
+// mergeConstLabels combines the constant label sources, rejecting names
+// that are not valid Prometheus label names, appear in more than one source
+// or collide with a metric's own labels.
+func mergeConstLabels(sources map[string]prometheus.Labels) (prometheus.Labels, error) {
+	merged := prometheus.Labels{}
+	from := map[string]string{}
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, src := range names {
+		for k, v := range sources[src] {
+			if err := scraper.ValidateExtraLabel(k); err != nil {
+				return nil, fmt.Errorf("%w (from %s)", err, src)
+			}
+			if prev, ok := from[k]; ok {
+				return nil, fmt.Errorf("constant label %q is set by both %s and %s", k, prev, src)
+			}
+			merged[k] = v
+			from[k] = src
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,25 @@
+// Command temporal-version-exporter exports the version of Temporal
+// clusters as Prometheus metrics. The exporter itself lives in the scraper
+// package so that it can also be embedded in other binaries; this command
+// adds its flags, subcommands and systemd integration. Run it with -h for
+// its commands and flags.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// Restore the default handling, so that a second signal exits at
+		// once instead of waiting for the shutdown.
+		<-ctx.Done()
+		stop()
+	}()
+	os.Exit(execute(ctx, os.Args[1:]))
+}
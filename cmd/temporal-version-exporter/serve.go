@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/coreos/go-systemd/v22/daemon"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"temporal-version-exporter/scraper"
+)
+
+// runServe runs the serve command: the exporter as configured by c until
+// ctx is cancelled. The --version, --once, --generate-dashboard and
+// --generate-rules flags still select their modes instead.
+func runServe(ctx context.Context, c *config) error {
+	if c.showVersion {
+		fmt.Println(scraper.VersionString())
+		os.Exit(0)
+	}
+	if c.once {
+		os.Exit(runOnceCommand(ctx, c))
+	}
+	if c.generateDashboard {
+		if err := scraper.WriteDashboard(os.Stdout, c.metricPrefix); err != nil {
+			fatal("writing dashboard failed", "err", err)
+		}
+		os.Exit(0)
+	}
+	if c.generateRules {
+		if err := scraper.WriteRules(os.Stdout, c.metricPrefix); err != nil {
+			fatal("writing rules failed", "err", err)
+		}
+		os.Exit(0)
+	}
+
+	if err := c.validateServe(); err != nil {
+		fatal("invalid flags", "err", err)
+	}
+	s := c.newScraper()
+	// The Go and process collectors are opt-out.
+	if c.goCollector && !c.noGoMetrics {
+		s.Registry().MustRegister(collectors.NewGoCollector())
+	}
+	if c.procCollector && !c.noGoMetrics {
+		s.Registry().MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+
+	if c.targetsFile != "" {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				if err := s.ReloadTargets(); err != nil {
+					slog.Error("reloading the targets file failed; keeping the previous entries", "err", err)
+				}
+			}
+		}()
+	}
+
+	var httpDone <-chan error
+	if !c.disableHTTP {
+		httpDone = serveHTTP(ctx, s, c)
+	}
+	go func() {
+		select {
+		case <-s.Ready():
+			sdNotify(daemon.SdNotifyReady)
+		case <-ctx.Done():
+		}
+	}()
+	go runWatchdog(s)
+
+	if err := s.Start(ctx); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+	case err := <-httpDone:
+		fatal("metrics http server failed", "err", err)
+	}
+	slog.Info("shutting down")
+	sdNotify(daemon.SdNotifyStopping)
+	s.Stop()
+	if httpDone != nil {
+		if err := <-httpDone; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateServe checks the serve flags that the scraper package does not
+// see: the aliases and the choice of outputs.
+func (c *config) validateServe() error {
+	for alias, name := range map[string]string{
+		"http-read-timeout": "web-read-timeout", "http-write-timeout": "web-write-timeout", "http-idle-timeout": "web-idle-timeout",
+		"server-tls-cert": "web-tls-cert-file", "server-tls-key": "web-tls-key-file",
+	} {
+		if c.flagSet(alias) && c.flagSet(name) {
+			return fmt.Errorf("--%s is an alias of --%s; set only one", alias, name)
+		}
+	}
+	if c.webMaxHeaderBytes <= 0 {
+		return errors.New("--web-max-header-bytes must be positive")
+	}
+	if c.disableHTTP && c.textfileOutput == "" && c.remoteWriteURL == "" {
+		return errors.New("--disable-http needs --textfile-output or --remote-write-url, or the metrics are not exported at all")
+	}
+	return nil
+}
+
+// newScraper returns the Scraper configured by the flags shared by the
+// serve and once commands. Invalid flags are fatal.
+func (c *config) newScraper() *scraper.Scraper {
+	labels, err := mergeConstLabels(map[string]prometheus.Labels{
+		"--label-from-env": c.labelsFromEnv.resolve(),
+		"--const-labels":   prometheus.Labels(c.constLabels),
+		"--extra-label":    prometheus.Labels(c.extraLabels),
+	})
+	if err != nil {
+		fatal("invalid constant labels", "err", err)
+	}
+
+	opts := []scraper.Option{
+		scraper.WithAddress(c.temporalAddr),
+		scraper.WithKubernetesSelector(c.k8sServiceSelector),
+		scraper.WithKubeconfig(c.kubeconfig),
+		scraper.WithDNSRefreshInterval(c.dnsRefreshInterval),
+		scraper.WithAPIKey(c.apiKey),
+		scraper.WithTimeouts(c.dialTimeout, c.requestTimeout),
+		scraper.WithMaxRetries(c.maxRetries),
+		scraper.WithInterceptors(splitList(c.grpcInterceptors)...),
+		scraper.WithScrapeInterval(c.scrapeInt),
+		scraper.WithAdaptiveBackoff(c.adaptiveThreshold, c.adaptiveMaxInterval),
+		scraper.WithSystemInfoRecheck(c.sysInfoRecheck),
+		scraper.WithSupportedClients(splitList(c.supportedClientsFilter)...),
+		scraper.WithMetricPrefix(c.metricPrefix),
+		scraper.WithExtraLabels(labels),
+		scraper.WithStaleHandling(c.staleHandling, c.staleDropAfter),
+		scraper.WithVersionHistorySize(c.versionHistorySize),
+		scraper.WithVersionPolicy(c.minVersion, c.expectedVersion, c.versionConstraint),
+		scraper.WithTargetsFile(c.targetsFile),
+		scraper.WithSupportWindow(c.supportWindow),
+		scraper.WithLatestReleaseCheck(c.latestCheckInterval),
+		scraper.WithWebhook(c.webhookURL, c.webhookSecret, c.webhookTimeout, c.webhookRetries),
+		scraper.WithPagerDuty(c.pagerDutyRoutingKey, c.pagerDutyThreshold),
+		scraper.WithRedis(c.redisAddr, c.redisPassword, c.redisDB),
+		scraper.WithAuditLog(c.auditLogPath, c.auditLogMaxSizeMB),
+		scraper.WithTextfile(c.textfileOutput),
+		scraper.WithRemoteWrite(c.remoteWriteURL, http.Header(c.remoteWriteHeaders), c.remoteWriteTimeout),
+	}
+	switch {
+	case c.useTLS || c.tlsCAFile != "" || c.tlsCertFile != "":
+		if c.flagSet("tls") && !c.useTLS {
+			fatal("invalid flags", "err", "--tls=false conflicts with --tls-ca-file and --tls-cert-file")
+		}
+		opts = append(opts, scraper.WithTLS(c.tlsCAFile, c.tlsCertFile, c.tlsKeyFile))
+	case c.flagSet("tls"):
+		// An explicit --tls=false is honoured for Temporal Cloud too.
+		opts = append(opts, scraper.WithoutTLS())
+	}
+	if c.tlsSkipVerify {
+		opts = append(opts, scraper.WithTLSInsecureSkipVerify())
+	}
+	if c.enableHealthProbe {
+		opts = append(opts, scraper.WithHealthProbe())
+	}
+	if c.offline {
+		opts = append(opts, scraper.WithOffline())
+	}
+	s, err := scraper.New(opts...)
+	if err != nil {
+		fatal("invalid flags", "err", err)
+	}
+	return s
+}
+
+// serverConfig returns the configuration of the HTTP endpoints given by
+// the flags.
+func (c *config) serverConfig() scraper.ServerConfig {
+	cfg := scraper.ServerConfig{
+		MetricsPath:            c.metricsPath,
+		WebConfigFile:          c.webConfigFile,
+		TLSCertFile:            c.webTLSCertFile,
+		TLSKeyFile:             c.webTLSKeyFile,
+		BasicAuthUsersFile:     c.basicAuthUsersFile,
+		MetricsUsername:        c.metricsUsername,
+		MetricsPassword:        c.metricsPassword,
+		AllowedCIDRs:           splitList(c.webAllowedCIDRs),
+		TrustProxy:             c.webTrustProxy,
+		AllowlistHealthz:       c.webAllowlistHealthz,
+		AccessLog:              c.webAccessLog,
+		AccessLogExcludeProbes: c.webAccessLogExcludeProbes,
+		ReadTimeout:            c.webReadTimeout,
+		ReadHeaderTimeout:      c.webReadHeaderTimeout,
+		WriteTimeout:           c.webWriteTimeout,
+		IdleTimeout:            c.webIdleTimeout,
+		MaxHeaderBytes:         c.webMaxHeaderBytes,
+		ShutdownGracePeriod:    c.shutdownGracePeriod,
+		MaxRequests:            c.metricsMaxRequests,
+		MetricsTimeout:         c.metricsTimeout,
+		RateLimit:              c.metricsRateLimit,
+		Pprof:                  c.enablePprof,
+		PprofListenAddr:        c.pprofListenAddr,
+		ReadyRequires:          c.readyRequires,
+		ReadyStrict:            c.readyStrict,
+		Settings:               c.settings(),
+	}
+	// --web.config.file rejects an explicit --server-tls-min-version only.
+	if c.flagSet("server-tls-min-version") {
+		cfg.TLSMinVersion = c.webTLSMinVersion
+	}
+	return cfg
+}
+
+// serveHTTP binds the HTTP endpoints of s and serves them as configured
+// by c in the background until ctx is cancelled. The returned channel
+// receives the result of serving. Invalid flags are fatal.
+func serveHTTP(ctx context.Context, s *scraper.Scraper, c *config) <-chan error {
+	srv, err := scraper.NewServer(s, c.serverConfig())
+	if err != nil {
+		fatal("invalid flags", "err", err)
+	}
+	// Bind before the first refresh, so that READY=1 implies the endpoints
+	// are reachable.
+	l, activated, err := listen(c.listenAddr)
+	if err != nil {
+		fatal("metrics http server failed", "err", err)
+	}
+	if activated {
+		slog.Info("using the socket passed by systemd", "listen_addr", l.Addr().String())
+	}
+	if c.basicAuthUsersFile != "" {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				if err := srv.ReloadUsers(); err != nil {
+					slog.Error("reloading basic auth users failed; keeping the previous ones", "err", err)
+				}
+			}
+		}()
+	}
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx, l) }()
+	return done
+}
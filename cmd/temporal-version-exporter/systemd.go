@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/activation"
+	"github.com/coreos/go-systemd/v22/daemon"
+
+	"temporal-version-exporter/scraper"
+)
+
+// sdNotify sends state to systemd. It is a no-op unless the exporter runs
+// as a Type=notify service.
+func sdNotify(state string) {
+	if _, err := daemon.SdNotify(false, state); err != nil {
+		slog.Warn("systemd notification failed", "state", state, "err", err)
+	}
+}
+
+// listen returns the listener for the HTTP endpoints: the socket passed in
+// by systemd socket activation if there is one, and a new listener on
+// addr otherwise. activated reports which.
+func listen(addr string) (l net.Listener, activated bool, err error) {
+	inherited, err := activation.Listeners()
+	if err != nil {
+		return nil, false, err
+	}
+	switch len(inherited) {
+	case 0:
+		l, err = net.Listen("tcp", addr)
+		return l, false, err
+	case 1:
+		if inherited[0] == nil {
+			return nil, false, errors.New("the socket passed by systemd is not a stream socket")
+		}
+		return inherited[0], true, nil
+	default:
+		return nil, false, fmt.Errorf("systemd passed %d sockets, expected one", len(inherited))
+	}
+}
+
+// runWatchdog pings the systemd watchdog at half its interval for as long
+// as refreshes keep completing, so that systemd restarts an exporter that
+// has hung. It returns at once if the watchdog is not enabled.
+func runWatchdog(s *scraper.Scraper) {
+	interval, err := daemon.SdWatchdogEnabled(false)
+	if err != nil {
+		slog.Warn("reading systemd watchdog settings failed", "err", err)
+		return
+	}
+	if interval == 0 {
+		return
+	}
+	for range time.Tick(interval / 2) {
+		if s.Stalled() {
+			slog.Warn("no refresh has completed recently; withholding systemd watchdog ping",
+				"last_refresh", s.LastRefresh())
+			continue
+		}
+		sdNotify(daemon.SdNotifyWatchdog)
+	}
+}
@@ -0,0 +1,84 @@
+package scraper
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// adaptiveMetrics are the series of adaptive backoff.
+type adaptiveMetrics struct {
+	effectiveIntervalGauge *prometheus.GaugeVec
+}
+
+func newAdaptiveMetrics() adaptiveMetrics {
+	return adaptiveMetrics{
+		effectiveIntervalGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "exporter_effective_scrape_interval_seconds",
+				Help: "Interval currently waited between refreshes of the target, longer than --scrape-interval while it is backed off after failures",
+			},
+			[]string{"address"},
+		),
+	}
+}
+
+func validateAdaptiveBackoff(c Config) error {
+	if c.AdaptiveThreshold < 0 {
+		return fmt.Errorf("--adaptive-backoff-threshold must not be negative, got %d", c.AdaptiveThreshold)
+	}
+	if c.AdaptiveMaxInterval < 0 {
+		return fmt.Errorf("--adaptive-max-interval must not be negative, got %s", c.AdaptiveMaxInterval)
+	}
+	return nil
+}
+
+// maxInterval is the longest backed-off scrape interval:
+// --adaptive-max-interval, or ten scrape intervals if it is unset.
+func (s *Scraper) maxInterval() time.Duration {
+	if s.cfg.AdaptiveMaxInterval != 0 {
+		return s.cfg.AdaptiveMaxInterval
+	}
+	return 10 * s.cfg.ScrapeInterval
+}
+
+// scrapeInterval returns the interval to wait after a refresh that left the
+// target with the given number of consecutive failures: the scrape
+// interval, doubled for each failure from --adaptive-backoff-threshold on,
+// up to maxInterval.
+func (s *Scraper) scrapeInterval(failures int) time.Duration {
+	interval := s.cfg.ScrapeInterval
+	if s.cfg.AdaptiveThreshold == 0 {
+		return interval
+	}
+	maxInterval := s.maxInterval()
+	for i := s.cfg.AdaptiveThreshold; i <= failures && interval < maxInterval; i++ {
+		interval *= 2
+	}
+	return min(interval, maxInterval)
+}
+
+// nextInterval returns the interval to wait before the next refresh of addr
+// and exports it, logging any change from prev. The time the next refresh
+// is due is kept for /debug/status.
+func (s *Scraper) nextInterval(addr string, prev time.Duration) time.Duration {
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+	st, ok := s.targetStates[addr]
+	failures := 0
+	if ok {
+		failures = st.failures
+	}
+	interval := s.scrapeInterval(failures)
+	if ok {
+		st.nextRefresh = time.Now().Add(interval)
+	}
+	s.effectiveIntervalGauge.WithLabelValues(addr).Set(interval.Seconds())
+	if prev != 0 && interval != prev {
+		slog.Info("scrape interval changed", "address", addr, "interval", interval, "previous_interval", prev,
+			"consecutive_failures", failures)
+	}
+	return interval
+}
@@ -0,0 +1,64 @@
+package scraper
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+func (srv *Server) parseAllowedCIDRs() error {
+	for _, s := range srv.cfg.AllowedCIDRs {
+		p, err := netip.ParsePrefix(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("invalid --web-allowed-cidrs entry %q: %w", s, err)
+		}
+		srv.allowedPrefixes = append(srv.allowedPrefixes, p.Masked())
+	}
+	return nil
+}
+
+// clientAddr returns the address a request is checked against: the last
+// X-Forwarded-For entry, which the trusted proxy appended, with
+// --web-trust-proxy, and the TCP peer otherwise.
+func clientAddr(r *http.Request, trustProxy bool) (netip.Addr, bool) {
+	host := r.RemoteAddr
+	if trustProxy {
+		if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+			entries := strings.Split(xff[len(xff)-1], ",")
+			host = strings.TrimSpace(entries[len(entries)-1])
+		}
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// allowlistHandler wraps next so that only clients in --web-allowed-cidrs
+// reach it. It returns next unchanged if no CIDRs are configured.
+func (srv *Server) allowlistHandler(next http.Handler) http.Handler {
+	if len(srv.allowedPrefixes) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" && !srv.cfg.AllowlistHealthz {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if addr, ok := clientAddr(r, srv.cfg.TrustProxy); ok {
+			for _, p := range srv.allowedPrefixes {
+				if p.Contains(addr) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	})
+}
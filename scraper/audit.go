@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// auditRecord is one line of the audit log.
+type auditRecord struct {
+	Timestamp   string `json:"timestamp"`
+	Address     string `json:"address"`
+	ClusterName string `json:"cluster_name"`
+	OldVersion  string `json:"old_version"`
+	NewVersion  string `json:"new_version"`
+	ChangeType  string `json:"change_type"`
+}
+
+// openAuditLog sets up the audit log if --audit-log-path is set. Rotated
+// files are kept.
+func (s *Scraper) openAuditLog() error {
+	if s.cfg.AuditLogPath == "" {
+		return nil
+	}
+	if s.cfg.AuditLogMaxSizeMB < 1 {
+		return fmt.Errorf("--audit-log-max-size-mb must be at least 1, got %d", s.cfg.AuditLogMaxSizeMB)
+	}
+	s.auditLog = &lumberjack.Logger{
+		Filename: s.cfg.AuditLogPath,
+		MaxSize:  s.cfg.AuditLogMaxSizeMB,
+	}
+	return nil
+}
+
+// auditVersionChange records the first detection of a version (oldVersion
+// empty) or a change of it. It must be called with s.metricsMu held,
+// which keeps the records of a target in order.
+func (s *Scraper) auditVersionChange(addr, clusterName, oldVersion, newVersion string, rollback bool) {
+	if s.auditLog == nil {
+		return
+	}
+	changeType := "upgrade"
+	switch {
+	case oldVersion == "":
+		changeType = "initial"
+	case rollback:
+		changeType = "rollback"
+	}
+	line, err := json.Marshal(auditRecord{
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Address:     addr,
+		ClusterName: clusterName,
+		OldVersion:  oldVersion,
+		NewVersion:  newVersion,
+		ChangeType:  changeType,
+	})
+	if err == nil {
+		_, err = s.auditLog.Write(append(line, '\n'))
+	}
+	if err != nil {
+		slog.Error("writing audit log failed", "path", s.cfg.AuditLogPath, "address", addr, "err", err)
+	}
+}
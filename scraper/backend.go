@@ -0,0 +1,52 @@
+package scraper
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricBackend receives the core results of refreshing a target. The
+// version parts are those returned by splitVersion, and source names the
+// RPC the version came from. Every method but ObserveScrapeDuration is
+// called with the Scraper's metrics lock held.
+type MetricBackend interface {
+	SetVersion(addr, version, prerelease, revision, build, source string)
+	ClearVersion(addr string)
+	SetUnknown(addr string)
+	ClearUnknown(addr string)
+	ObserveScrapeDuration(addr string, d time.Duration)
+	IncrError(addr, errorType string)
+}
+
+// prometheusBackend records results in the Scraper's Prometheus metrics.
+type prometheusBackend struct{ s *Scraper }
+
+// SetVersion replaces whatever version series addr had, so exactly one
+// remains after an upgrade or a switch of source.
+func (b prometheusBackend) SetVersion(addr, version, prerelease, revision, build, source string) {
+	b.s.versionGauge.DeletePartialMatch(prometheus.Labels{"address": addr})
+	b.s.versionGauge.WithLabelValues(addr, version, prerelease, revision, build, source).Set(1)
+}
+
+func (b prometheusBackend) ClearVersion(addr string) {
+	b.s.versionGauge.DeletePartialMatch(prometheus.Labels{"address": addr})
+}
+
+func (b prometheusBackend) SetUnknown(addr string) {
+	b.s.unknownGauge.WithLabelValues(addr).Set(1)
+	b.s.upGauge.WithLabelValues(addr).Set(0)
+}
+
+func (b prometheusBackend) ClearUnknown(addr string) {
+	b.s.unknownGauge.WithLabelValues(addr).Set(0)
+	b.s.upGauge.WithLabelValues(addr).Set(1)
+}
+
+func (b prometheusBackend) ObserveScrapeDuration(addr string, d time.Duration) {
+	b.s.scrapeDuration.WithLabelValues(addr).Observe(d.Seconds())
+}
+
+func (b prometheusBackend) IncrError(addr, errorType string) {
+	b.s.scrapeErrors.WithLabelValues(addr, errorType).Inc()
+}
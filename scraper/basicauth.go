@@ -0,0 +1,180 @@
+package scraper
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// authMetrics are the series of HTTP basic authentication.
+type authMetrics struct {
+	authFailures *prometheus.CounterVec
+}
+
+func newAuthMetrics() authMetrics {
+	return authMetrics{
+		authFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "exporter_http_auth_failures_total",
+				Help: "Number of HTTP requests rejected by basic auth, by reason: missing or invalid credentials",
+			},
+			[]string{"reason"},
+		),
+	}
+}
+
+// dummyHash is compared against for unknown users, so that a request takes
+// as long whether or not its username exists.
+var dummyHash = sync.OnceValue(func() []byte {
+	h, _ := bcrypt.GenerateFromPassword([]byte("unused"), bcrypt.DefaultCost)
+	return h
+})
+
+// readBasicAuthUsers parses a users file. Blank lines and lines starting
+// with # are ignored.
+func readBasicAuthUsers(path string) (map[string][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	users := map[string][]byte{}
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf("%s:%d: expected username:bcrypt-hash", path, n)
+		}
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid bcrypt hash for %s: %w", path, n, user, err)
+		}
+		users[user] = []byte(hash)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// basicAuthHandler wraps next with basic auth if --web-basic-auth-users-file
+// is set. A file that fails to load is an error.
+func (srv *Server) basicAuthHandler(next http.Handler) (http.Handler, error) {
+	if srv.cfg.BasicAuthUsersFile == "" {
+		return next, nil
+	}
+	users, err := readBasicAuthUsers(srv.cfg.BasicAuthUsersFile)
+	if err != nil {
+		return nil, err
+	}
+	srv.authUsers = users
+	dummyHash()
+
+	s := srv.s
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		user, pass, ok := r.BasicAuth()
+		if !ok {
+			s.authFailures.WithLabelValues("missing").Inc()
+			unauthorized(w)
+			return
+		}
+		srv.authMu.RLock()
+		hash, known := srv.authUsers[user]
+		srv.authMu.RUnlock()
+		if !known {
+			hash = dummyHash()
+		}
+		// bcrypt compares in constant time.
+		if err := bcrypt.CompareHashAndPassword(hash, []byte(pass)); err != nil || !known {
+			s.authFailures.WithLabelValues("invalid").Inc()
+			unauthorized(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), nil
+}
+
+// ReloadUsers re-reads --web-basic-auth-users-file, if it is set. The
+// previous users are kept if the file fails to load.
+func (srv *Server) ReloadUsers() error {
+	if srv.cfg.BasicAuthUsersFile == "" {
+		return nil
+	}
+	users, err := readBasicAuthUsers(srv.cfg.BasicAuthUsersFile)
+	if err != nil {
+		return err
+	}
+	srv.authMu.Lock()
+	srv.authUsers = users
+	srv.authMu.Unlock()
+	slog.Info("reloaded basic auth users", "users", len(users))
+	return nil
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="temporal-version-exporter", charset="UTF-8"`)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+func (srv *Server) validateMetricsAuth() error {
+	c := srv.cfg
+	if (c.MetricsUsername == "") != (c.MetricsPassword == "") {
+		return errors.New("--metrics-username and --metrics-password must be set together")
+	}
+	// Both would apply to the metrics endpoint, and a single Authorization
+	// header cannot carry two sets of credentials.
+	if c.MetricsUsername != "" && c.BasicAuthUsersFile != "" {
+		return errors.New("--metrics-username cannot be combined with --web-basic-auth-users-file; add the user to the file instead")
+	}
+	return nil
+}
+
+// metricsAuthHandler wraps next, the metrics handler, with basic auth
+// against --metrics-username and --metrics-password if they are set.
+// Requests without credentials get 401, requests with wrong ones 403.
+func (srv *Server) metricsAuthHandler(next http.Handler) http.Handler {
+	if srv.cfg.MetricsUsername == "" {
+		return next
+	}
+	// Comparing digests keeps the comparison constant-time regardless of
+	// the lengths.
+	wantUser := sha256.Sum256([]byte(srv.cfg.MetricsUsername))
+	wantPass := sha256.Sum256([]byte(srv.cfg.MetricsPassword))
+	s := srv.s
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok {
+			s.authFailures.WithLabelValues("missing").Inc()
+			w.Header().Set("WWW-Authenticate", `Basic realm="metrics", charset="UTF-8"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		gotUser := sha256.Sum256([]byte(user))
+		gotPass := sha256.Sum256([]byte(pass))
+		userOK := subtle.ConstantTimeCompare(gotUser[:], wantUser[:])
+		passOK := subtle.ConstantTimeCompare(gotPass[:], wantPass[:])
+		if userOK&passOK != 1 {
+			s.authFailures.WithLabelValues("invalid").Inc()
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
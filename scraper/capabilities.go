@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "go.temporal.io/api/workflowservice/v1"
+)
+
+// capabilityMetrics are the series of the server capabilities.
+type capabilityMetrics struct {
+	capabilityGauge         *prometheus.GaugeVec
+	sysInfoUnsupportedGauge *prometheus.GaugeVec
+}
+
+func newCapabilityMetrics() capabilityMetrics {
+	return capabilityMetrics{
+		capabilityGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_capability",
+				Help: "Set to 1 if the server reports the capability in GetSystemInfo, 0 otherwise, including on servers too old to know it",
+			},
+			[]string{"address", "capability"},
+		),
+		sysInfoUnsupportedGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_system_info_unsupported",
+				Help: "Set to 1 if the server answered GetSystemInfo with Unimplemented, 0 once it has answered it successfully",
+			},
+			[]string{"address"},
+		),
+	}
+}
+
+// skipSystemInfo reports whether GetSystemInfo is known to be unimplemented
+// on the target and is not yet due to be re-checked. It must be called with
+// s.metricsMu held.
+func (s *Scraper) skipSystemInfo(st *targetState) bool {
+	return !st.sysInfoUnsupportedAt.IsZero() && time.Since(st.sysInfoUnsupportedAt) < s.cfg.SystemInfoRecheck
+}
+
+// capabilities maps each GetSystemInfo capability to its label value. The
+// generated getters are nil-safe, so servers that omit the message or the
+// field report 0.
+var capabilities = []struct {
+	name string
+	get  func(*v1.GetSystemInfoResponse_Capabilities) bool
+}{
+	{"signal_and_query_header", (*v1.GetSystemInfoResponse_Capabilities).GetSignalAndQueryHeader},
+	{"internal_error_differentiation", (*v1.GetSystemInfoResponse_Capabilities).GetInternalErrorDifferentiation},
+	{"activity_failure_include_heartbeat", (*v1.GetSystemInfoResponse_Capabilities).GetActivityFailureIncludeHeartbeat},
+	{"supports_schedules", (*v1.GetSystemInfoResponse_Capabilities).GetSupportsSchedules},
+	{"encoded_failure_attributes", (*v1.GetSystemInfoResponse_Capabilities).GetEncodedFailureAttributes},
+	{"build_id_based_versioning", (*v1.GetSystemInfoResponse_Capabilities).GetBuildIdBasedVersioning},
+	{"upsert_memo", (*v1.GetSystemInfoResponse_Capabilities).GetUpsertMemo},
+	{"eager_workflow_start", (*v1.GetSystemInfoResponse_Capabilities).GetEagerWorkflowStart},
+	{"sdk_metadata", (*v1.GetSystemInfoResponse_Capabilities).GetSdkMetadata},
+	{"count_group_by_execution_status", (*v1.GetSystemInfoResponse_Capabilities).GetCountGroupByExecutionStatus},
+	{"nexus", (*v1.GetSystemInfoResponse_Capabilities).GetNexus},
+}
+
+// setCapabilities sets every known capability series for addr; caps may be
+// nil. It must be called with s.metricsMu held.
+func (s *Scraper) setCapabilities(addr string, caps *v1.GetSystemInfoResponse_Capabilities) {
+	for _, c := range capabilities {
+		v := 0.0
+		if c.get(caps) {
+			v = 1
+		}
+		s.capabilityGauge.WithLabelValues(addr, c.name).Set(v)
+	}
+}
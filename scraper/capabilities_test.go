@@ -0,0 +1,148 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "go.temporal.io/api/workflowservice/v1"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkCapabilities fails the test unless every capability series of s is
+// 1 for the capabilities in want and 0 for the others.
+func checkCapabilities(t *testing.T, s *Scraper, want ...string) {
+	t.Helper()
+	if n := testutil.CollectAndCount(s.capabilityGauge); n != len(capabilities) {
+		t.Errorf("%d capability series, want %d", n, len(capabilities))
+	}
+	set := make(map[string]bool, len(want))
+	for _, name := range want {
+		set[name] = true
+	}
+	for _, c := range capabilities {
+		wantV := 0.0
+		if set[c.name] {
+			wantV = 1
+		}
+		if v := testutil.ToFloat64(s.capabilityGauge.WithLabelValues(testAddr, c.name)); v != wantV {
+			t.Errorf("capability %s = %v, want %v", c.name, v, wantV)
+		}
+	}
+}
+
+// answerSystemInfo makes f answer GetSystemInfo with version 1.23.0 and
+// caps.
+func answerSystemInfo(f *fakeFrontend, caps *v1.GetSystemInfoResponse_Capabilities) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.systemInfo = func(context.Context) (*v1.GetSystemInfoResponse, error) {
+		return &v1.GetSystemInfoResponse{ServerVersion: "1.23.0", Capabilities: caps}, nil
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	tests := []struct {
+		name string
+		caps *v1.GetSystemInfoResponse_Capabilities
+		want []string
+	}{
+		{"no capabilities message", nil, nil},
+		{"empty capabilities", &v1.GetSystemInfoResponse_Capabilities{}, nil},
+		{
+			"single capability",
+			&v1.GetSystemInfoResponse_Capabilities{Nexus: true},
+			[]string{"nexus"},
+		},
+		{
+			"several capabilities",
+			&v1.GetSystemInfoResponse_Capabilities{SupportsSchedules: true, UpsertMemo: true, SdkMetadata: true},
+			[]string{"supports_schedules", "upsert_memo", "sdk_metadata"},
+		},
+		{
+			"all capabilities",
+			&v1.GetSystemInfoResponse_Capabilities{
+				SignalAndQueryHeader:            true,
+				InternalErrorDifferentiation:    true,
+				ActivityFailureIncludeHeartbeat: true,
+				SupportsSchedules:               true,
+				EncodedFailureAttributes:        true,
+				BuildIdBasedVersioning:          true,
+				UpsertMemo:                      true,
+				EagerWorkflowStart:              true,
+				SdkMetadata:                     true,
+				CountGroupByExecutionStatus:     true,
+				Nexus:                           true,
+			},
+			[]string{
+				"signal_and_query_header", "internal_error_differentiation", "activity_failure_include_heartbeat",
+				"supports_schedules", "encoded_failure_attributes", "build_id_based_versioning", "upsert_memo",
+				"eager_workflow_start", "sdk_metadata", "count_group_by_execution_status", "nexus",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeFrontend{}
+			answerSystemInfo(f, tt.caps)
+			s, stop := newTestScraper(t, f)
+			defer stop()
+			refreshOnce(t, s)
+			checkCapabilities(t, s, tt.want...)
+			if v := testutil.ToFloat64(s.sysInfoUnsupportedGauge.WithLabelValues(testAddr)); v != 0 {
+				t.Errorf("system info unsupported = %v, want 0", v)
+			}
+		})
+	}
+}
+
+func TestCapabilitiesFollowServer(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	f := &fakeFrontend{}
+	s, stop := newTestScraper(t, f)
+	defer stop()
+
+	answerSystemInfo(f, &v1.GetSystemInfoResponse_Capabilities{SupportsSchedules: true, SdkMetadata: true})
+	refreshOnce(t, s)
+	checkCapabilities(t, s, "supports_schedules", "sdk_metadata")
+
+	// An upgrade that adds one capability and a downgrade that drops one.
+	answerSystemInfo(f, &v1.GetSystemInfoResponse_Capabilities{SupportsSchedules: true, SdkMetadata: true, Nexus: true})
+	refreshOnce(t, s)
+	checkCapabilities(t, s, "supports_schedules", "sdk_metadata", "nexus")
+	answerSystemInfo(f, &v1.GetSystemInfoResponse_Capabilities{SupportsSchedules: true})
+	refreshOnce(t, s)
+	checkCapabilities(t, s, "supports_schedules")
+
+	// A failed GetSystemInfo says nothing about the capabilities.
+	f.mu.Lock()
+	f.systemInfo = func(context.Context) (*v1.GetSystemInfoResponse, error) {
+		return nil, status.Error(codes.Internal, "boom")
+	}
+	f.mu.Unlock()
+	refreshOnce(t, s)
+	checkCapabilities(t, s, "supports_schedules")
+}
+
+func TestCapabilitiesOfServerWithoutSystemInfo(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	f := &fakeFrontend{
+		clusterInfo: func(context.Context) (*v1.GetClusterInfoResponse, error) {
+			return &v1.GetClusterInfoResponse{ServerVersion: "1.14.6"}, nil
+		},
+	}
+	s, stop := newTestScraper(t, f)
+	defer stop()
+
+	res := refreshOnce(t, s)
+	if res.Version != "1.14.6" {
+		t.Errorf("version = %q, want 1.14.6", res.Version)
+	}
+	checkCapabilities(t, s)
+	if v := testutil.ToFloat64(s.sysInfoUnsupportedGauge.WithLabelValues(testAddr)); v != 1 {
+		t.Errorf("system info unsupported = %v, want 1", v)
+	}
+}
@@ -0,0 +1,118 @@
+package scraper
+
+import (
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "go.temporal.io/api/workflowservice/v1"
+)
+
+// clusterMetrics are the series of GetClusterInfo.
+type clusterMetrics struct {
+	clusterInfoGauge       *prometheus.GaugeVec
+	shardCountGauge        *prometheus.GaugeVec
+	persistenceInfoGauge   *prometheus.GaugeVec
+	initialFailoverGauge   *prometheus.GaugeVec
+	failoverIncrementGauge *prometheus.GaugeVec
+	supportedClientGauge   *prometheus.GaugeVec
+}
+
+func newClusterMetrics() clusterMetrics {
+	return clusterMetrics{
+		clusterInfoGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "cluster_info",
+				Help: "Always 1; labeled with the cluster name and ID reported by GetClusterInfo. Labels are empty until GetClusterInfo succeeds once",
+			},
+			[]string{"address", "cluster_name", "cluster_id"},
+		),
+		shardCountGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "cluster_history_shards",
+				Help: "Number of history shards reported by the latest GetClusterInfo. Absent if it failed or did not report it",
+			},
+			[]string{"address"},
+		),
+		persistenceInfoGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "cluster_persistence_info",
+				Help: "Always 1; labeled with the persistence and visibility stores reported by GetClusterInfo. Absent while GetClusterInfo fails",
+			},
+			[]string{"address", "persistence_store", "visibility_store"},
+		),
+		initialFailoverGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "cluster_initial_failover_version",
+				Help: "Initial failover version reported by GetClusterInfo; the last known value is kept while the call fails",
+			},
+			[]string{"address"},
+		),
+		failoverIncrementGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "cluster_failover_version_increment",
+				Help: "Failover version increment reported by GetClusterInfo; the last known value is kept while the call fails",
+			},
+			[]string{"address"},
+		),
+		supportedClientGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "cluster_supported_client_info",
+				Help: "Always 1; labeled with each client and the minimum version of it the server supports, limited by --supported-clients-filter. The last known set is kept while GetClusterInfo fails",
+			},
+			[]string{"address", "client", "min_version"},
+		),
+	}
+}
+
+// updateClusterInfo exports the metrics derived from a GetClusterInfo
+// response; resp is nil if the call failed. The identity is cached so a
+// failed call does not flap the labels. It must be called with s.metricsMu
+// held.
+func (s *Scraper) updateClusterInfo(addr string, st *targetState, resp *v1.GetClusterInfoResponse) {
+	s.persistenceInfoGauge.DeletePartialMatch(prometheus.Labels{"address": addr})
+	if resp != nil {
+		s.persistenceInfoGauge.WithLabelValues(addr, resp.GetPersistenceStore(), resp.GetVisibilityStore()).Set(1)
+	}
+	if resp != nil {
+		st.failover = &failoverVersions{
+			Initial:   resp.GetInitialFailoverVersion(),
+			Increment: resp.GetFailoverVersionIncrement(),
+		}
+	}
+	if st.failover != nil {
+		s.initialFailoverGauge.WithLabelValues(addr).Set(float64(st.failover.Initial))
+		s.failoverIncrementGauge.WithLabelValues(addr).Set(float64(st.failover.Increment))
+	}
+	if resp != nil {
+		s.supportedClientGauge.DeletePartialMatch(prometheus.Labels{"address": addr})
+		for client, minVersion := range resp.GetSupportedClients() {
+			if s.supportedClientWanted(client) {
+				s.supportedClientGauge.WithLabelValues(addr, client, minVersion).Set(1)
+			}
+		}
+	}
+	if n := resp.GetHistoryShardCount(); n > 0 {
+		s.shardCountGauge.WithLabelValues(addr).Set(float64(n))
+	} else {
+		s.shardCountGauge.DeleteLabelValues(addr)
+	}
+
+	if resp != nil && (resp.GetClusterName() != "" || resp.GetClusterId() != "") {
+		st.clusterName = resp.GetClusterName()
+		st.clusterID = resp.GetClusterId()
+	}
+	s.clusterInfoGauge.DeletePartialMatch(prometheus.Labels{"address": addr})
+	s.clusterInfoGauge.WithLabelValues(addr, st.clusterName, st.clusterID).Set(1)
+}
+
+// failoverVersions are a cluster's static replication settings.
+type failoverVersions struct {
+	Initial   int64 `json:"initial_failover_version"`
+	Increment int64 `json:"failover_version_increment"`
+}
+
+// supportedClientWanted reports whether client passes
+// --supported-clients-filter.
+func (s *Scraper) supportedClientWanted(client string) bool {
+	return len(s.cfg.SupportedClients) == 0 || slices.Contains(s.cfg.SupportedClients, client)
+}
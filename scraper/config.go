@@ -0,0 +1,46 @@
+package scraper
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Setting is the effective value of a setting shown by /config and where
+// it came from: flag, env or default. Env names the environment variable
+// it was read from.
+type Setting struct {
+	Value  string `yaml:"value"`
+	Source string `yaml:"source"`
+	Env    string `yaml:"env,omitempty"`
+}
+
+type effectiveConfig struct {
+	Targets []string           `yaml:"targets"`
+	Flags   map[string]Setting `yaml:"flags"`
+}
+
+// configHandler serves the effective configuration as YAML: the current
+// targets and every setting with its value and source.
+func (srv *Server) configHandler(w http.ResponseWriter, r *http.Request) {
+	s := srv.s
+	cfg := effectiveConfig{Flags: srv.cfg.Settings, Targets: []string{}}
+	if cfg.Flags == nil {
+		cfg.Flags = map[string]Setting{}
+	}
+	s.runnersMu.Lock()
+	for addr := range s.runners {
+		cfg.Targets = append(cfg.Targets, addr)
+	}
+	s.runnersMu.Unlock()
+	slices.Sort(cfg.Targets)
+
+	w.Header().Set("Content-Type", "application/yaml")
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		slog.Error("writing config failed", "err", err)
+	}
+}
@@ -0,0 +1,278 @@
+package scraper
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// cloudSuffixes identify Temporal Cloud endpoints.
+var cloudSuffixes = []string{".tmprl.cloud", ".temporal.io"}
+
+func isCloudAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, suffix := range cloudSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAddr returns addr in the canonical host:port form used in the
+// address label: IP addresses in their shortest form, IPv6 ones in
+// brackets, and host names in lower case. An unbracketed IPv6 address is an
+// error; an address without a port is returned unchanged.
+func normalizeAddr(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		if strings.Count(addr, ":") > 1 && !strings.HasPrefix(addr, "[") {
+			return "", fmt.Errorf("invalid address %q: IPv6 addresses must be in brackets, as in [::1]:7233", addr)
+		}
+		return addr, nil
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		host = ip.String()
+	} else {
+		host = strings.ToLower(host)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// connConfig is the resolved transport configuration for a target.
+type connConfig struct {
+	tls    bool
+	apiKey string
+	// rootCAs verifies the frontend; nil means the system roots.
+	rootCAs *x509.CertPool
+	// certFile and keyFile are the client certificate, if any.
+	certFile, keyFile  string
+	insecureSkipVerify bool
+}
+
+// resolveConnConfig applies Temporal Cloud defaults for addr on top of the
+// Scraper's configuration.
+func resolveConnConfig(addr string, c Config) (connConfig, error) {
+	cfg := connConfig{tls: c.TLS, apiKey: c.APIKey, certFile: c.TLSCertFile, keyFile: c.TLSKeyFile, insecureSkipVerify: c.TLSInsecureSkipVerify}
+	if c.TLSCAFile != "" {
+		pem, err := os.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.rootCAs = x509.NewCertPool()
+		if !cfg.rootCAs.AppendCertsFromPEM(pem) {
+			return cfg, fmt.Errorf("no certificates found in %s", c.TLSCAFile)
+		}
+	}
+	if !isCloudAddress(addr) {
+		return cfg, nil
+	}
+	switch {
+	case !c.TLSSet:
+		cfg.tls = true
+		slog.Info("auto-enabled TLS for Temporal Cloud endpoint", "address", addr)
+	case !cfg.tls:
+		slog.Warn("TLS explicitly disabled for Temporal Cloud endpoint", "address", addr)
+	}
+	if cfg.apiKey == "" {
+		return cfg, errors.New("Temporal Cloud endpoint " + addr + " requires --api-key or TEMPORAL_API_KEY")
+	}
+	return cfg, nil
+}
+
+// dialOptions returns the options to dial addr with. verify checks the
+// frontend's certificate after the standard verification.
+func (c connConfig) dialOptions(verify func(tls.ConnectionState) error) []grpc.DialOption {
+	opts := []grpc.DialOption{grpc.WithUserAgent(userAgent())}
+	if c.tls {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			MinVersion:           tls.VersionTLS12,
+			RootCAs:              c.rootCAs,
+			InsecureSkipVerify:   c.insecureSkipVerify,
+			VerifyConnection:     verify,
+			GetClientCertificate: c.clientCertificate,
+		})))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	if c.apiKey != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(apiKeyCredentials{key: c.apiKey, requireTLS: c.tls}))
+	}
+	return opts
+}
+
+// clientCertificate loads the client certificate at every handshake, so a
+// rotated certificate is used once the connection is re-established. It
+// presents none if no certificate is configured.
+func (c connConfig) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if c.certFile == "" {
+		return &tls.Certificate{}, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS client certificate: %w", err)
+	}
+	return &cert, nil
+}
+
+// apiKeyCredentials attaches the API key as a bearer token to every RPC.
+type apiKeyCredentials struct {
+	key        string
+	requireTLS bool
+}
+
+func (c apiKeyCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": fmt.Sprintf("Bearer %s", c.key)}, nil
+}
+
+func (c apiKeyCredentials) RequireTransportSecurity() bool { return c.requireTLS }
+
+// connMetrics are the series of the gRPC connections.
+type connMetrics struct {
+	connStateGauge  *prometheus.GaugeVec
+	rpcDuration     *prometheus.HistogramVec
+	connTransitions *prometheus.CounterVec
+}
+
+func newConnMetrics() connMetrics {
+	return connMetrics{
+		connStateGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "exporter_grpc_connectivity_state",
+				Help: "Set to 1 for the current connectivity state of the gRPC connection to the target and 0 for the others",
+			},
+			[]string{"address", "state"},
+		),
+		rpcDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "exporter_grpc_request_duration_seconds",
+				Help:    "Round-trip latency of RPCs to the target, excluding dialing",
+				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 5},
+			},
+			[]string{"address", "method"},
+		),
+		connTransitions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "exporter_grpc_state_transitions_total",
+				Help: "Number of connectivity state transitions of the gRPC connection to the target",
+			},
+			[]string{"address", "from_state", "to_state"},
+		),
+	}
+}
+
+var connStates = []connectivity.State{
+	connectivity.Idle, connectivity.Connecting, connectivity.Ready,
+	connectivity.TransientFailure, connectivity.Shutdown,
+}
+
+func stateLabel(s connectivity.State) string { return strings.ToLower(s.String()) }
+
+// dial returns the cached connection for addr, dialing it on first use or
+// once the cached one has shut down, within --grpc-dial-timeout. A failed
+// dial is not cached, so the next refresh tries again.
+func (s *Scraper) dial(ctx context.Context, addr string, cfg connConfig) (*grpc.ClientConn, error) {
+	if v, ok := s.connections.Load(addr); ok {
+		conn := v.(*grpc.ClientConn)
+		if conn.GetState() != connectivity.Shutdown {
+			return conn, nil
+		}
+		s.connections.CompareAndDelete(addr, conn)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.DialTimeout)
+	defer cancel()
+	opts := append(cfg.dialOptions(s.recordPeerCertificate(addr)), grpc.WithBlock(), grpc.WithChainUnaryInterceptor(s.interceptorChain(addr)...))
+	if s.dialer != nil {
+		opts = append(opts, grpc.WithContextDialer(s.dialer))
+	}
+	conn, err := grpc.DialContext(ctx, addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// A forced refresh may have dialed addr at the same time.
+	if v, loaded := s.connections.LoadOrStore(addr, conn); loaded {
+		conn.Close()
+		return v.(*grpc.ClientConn), nil
+	}
+	s.bg.Go(func() { s.watchConnState(addr, conn) })
+	return conn, nil
+}
+
+// closeConn closes and forgets the cached connection for addr, if any.
+func (s *Scraper) closeConn(addr string) {
+	if v, ok := s.connections.LoadAndDelete(addr); ok {
+		v.(*grpc.ClientConn).Close()
+	}
+}
+
+// watchConnState counts state transitions of conn until it shuts down.
+func (s *Scraper) watchConnState(addr string, conn *grpc.ClientConn) {
+	from := conn.GetState()
+	for from != connectivity.Shutdown {
+		if !conn.WaitForStateChange(context.Background(), from) {
+			return
+		}
+		to := conn.GetState()
+		s.connTransitions.WithLabelValues(addr, stateLabel(from), stateLabel(to)).Inc()
+		from = to
+	}
+}
+
+// setConnState records the current state of conn. It must be called with
+// s.metricsMu held.
+func (s *Scraper) setConnState(addr string, state connectivity.State) {
+	for _, cs := range connStates {
+		v := 0.0
+		if cs == state {
+			v = 1
+		}
+		s.connStateGauge.WithLabelValues(addr, stateLabel(cs)).Set(v)
+	}
+}
+
+// metricsInterceptor records the latency of every unary RPC made to addr.
+func (s *Scraper) metricsInterceptor(addr string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		defer func() {
+			s.rpcDuration.WithLabelValues(addr, methodLabel(method)).Observe(time.Since(start).Seconds())
+		}()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// methodLabel turns "/pkg.Service/GetSystemInfo" into "get_system_info".
+func methodLabel(fullMethod string) string {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
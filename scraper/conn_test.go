@@ -0,0 +1,148 @@
+package scraper
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/goleak"
+)
+
+func TestNormalizeAddr(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "[::1]:7233", want: "[::1]:7233"},
+		{in: "[0:0:0:0:0:0:0:1]:7233", want: "[::1]:7233"},
+		{in: "[2001:DB8:0:0:0:0:0:1]:7233", want: "[2001:db8::1]:7233"},
+		{in: "[2001:db8::1]:7233", want: "[2001:db8::1]:7233"},
+		{in: "[::ffff:10.0.0.1]:7233", want: "[::ffff:10.0.0.1]:7233"},
+		{in: "[fe80::1%eth0]:7233", want: "[fe80::1%eth0]:7233"},
+		{in: "127.0.0.1:7233", want: "127.0.0.1:7233"},
+		{in: "Temporal-Frontend.Example.COM:7233", want: "temporal-frontend.example.com:7233"},
+		{in: "localhost:7233", want: "localhost:7233"},
+		// Without a port, the address is kept for the error of the dial.
+		{in: "temporal", want: "temporal"},
+		{in: "::1", wantErr: true},
+		{in: "2001:db8::1:7233", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeAddr(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("normalizeAddr(%q) = %q, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("normalizeAddr(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+			continue
+		}
+		if again, _ := normalizeAddr(got); again != got {
+			t.Errorf("normalizeAddr(%q) = %q, not normalized", got, again)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("normalizeAddr(%q) = %q, not a valid label value", tt.in, got)
+		}
+		// A bracketed IPv6 address must stay one that can be dialed.
+		if strings.HasPrefix(got, "[") {
+			if _, err := netip.ParseAddrPort(got); err != nil {
+				t.Errorf("normalizeAddr(%q) = %q, which cannot be dialed: %v", tt.in, got, err)
+			}
+		}
+	}
+}
+
+func TestNormalizeTargets(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	got := normalizeTargets([]string{"[::1]:7233", "[0:0::1]:7233", "::1", "Frontend:7233", "frontend:7233", "10.0.0.1:7233"})
+	want := []string{"[::1]:7233", "frontend:7233", "10.0.0.1:7233"}
+	if !slices.Equal(got, want) {
+		t.Errorf("normalizeTargets = %q, want %q", got, want)
+	}
+}
+
+func TestIPv6Target(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	const norm = "[::1]:7233"
+	f := &fakeFrontend{}
+	f.setVersion("1.23.0")
+	s, stop := newTestScraper(t, f, WithAddress("[0:0:0:0:0:0:0:1]:7233"))
+	defer stop()
+	dial := s.dialer
+	var (
+		mu     sync.Mutex
+		dialed []string
+	)
+	s.dialer = func(ctx context.Context, addr string) (net.Conn, error) {
+		mu.Lock()
+		dialed = append(dialed, addr)
+		mu.Unlock()
+		return dial(ctx, addr)
+	}
+
+	res := refreshOnce(t, s)
+	if res.Address != norm || res.Err != nil {
+		t.Fatalf("refresh of %s: %+v", norm, res)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(dialed) == 0 || dialed[0] != norm {
+		t.Errorf("dialed %q, want %s", dialed, norm)
+	}
+	if v := testutil.ToFloat64(s.versionGauge.WithLabelValues(norm, "1.23.0", "", "", "", "system_info")); v != 1 {
+		t.Errorf("version series for address %s = %v, want 1", norm, v)
+	}
+}
+
+func TestLookupOnCachedConnection(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	const addr = "localhost:7233"
+	f := &fakeFrontend{}
+	f.setVersion("1.23.0")
+	s, stop := newTestScraper(t, f, WithAddress(addr))
+	defer stop()
+	// Unlike RefreshOnce, refresh leaves the connection open.
+	defer s.closeConn(addr)
+	dial := s.dialer
+	var dials atomic.Int32
+	s.dialer = func(ctx context.Context, addr string) (net.Conn, error) {
+		dials.Add(1)
+		return dial(ctx, addr)
+	}
+	cfg, err := resolveConnConfig(addr, s.cfg)
+	if err != nil {
+		t.Fatalf("resolveConnConfig: %v", err)
+	}
+	lookups := func() uint64 {
+		var m dto.Metric
+		if err := s.dnsDuration.WithLabelValues(addr).(prometheus.Histogram).Write(&m); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		return m.GetHistogram().GetSampleCount()
+	}
+
+	for i := range 2 {
+		if res := s.refresh(context.Background(), addr, cfg); res.Err != nil {
+			t.Fatalf("refresh %d: %v", i, res.Err)
+		}
+	}
+	if n := dials.Load(); n != 1 {
+		t.Fatalf("dialed %d times, want the connection of the first refresh reused", n)
+	}
+	if got := lookups(); got != 2 {
+		t.Errorf("%d lookups after two refreshes on one connection, want 2", got)
+	}
+}
@@ -0,0 +1,253 @@
+package scraper
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// versionRange is a semver range as accepted by --version-constraint:
+// alternatives separated by "||", each a set of comparisons that must all
+// hold, separated by spaces or commas:
+//
+//	>=1.23.0 <1.25.0    a comparison with =, !=, >, >=, < or <=
+//	1.23, 1.23.x, 1.x   a partial version or wildcard, any version in it
+//	~1.22.3             patch releases from 1.22.3 on, below 1.23.0
+//	^1.22.3             releases from 1.22.3 on, below 2.0.0
+//	1.22 - 1.24         an inclusive range of (partial) versions
+//
+// A pre-release only satisfies an alternative that names a pre-release of
+// the same MAJOR.MINOR.PATCH, so ">=1.23.0-rc.1" admits 1.23.0-rc.2 but
+// ">=1.22.0" does not admit 1.23.0-rc.1.
+type versionRange struct {
+	alternatives [][]comparison
+}
+
+// comparison is one test of a version against v. A != of a partial version
+// excludes the range [v, upper).
+type comparison struct {
+	op    string
+	v     semVersion
+	upper *semVersion
+}
+
+// partialRE matches a version in a constraint: MAJOR, MAJOR.MINOR or a full
+// version, where missing or x, X and * parts are wildcards. Pre-release and
+// build parts need a full version.
+var partialRE = regexp.MustCompile(`^v?(0|[1-9]\d*|[xX*])(?:\.(0|[1-9]\d*|[xX*])(?:\.(0|[1-9]\d*|[xX*])` +
+	`(?:-(` + prereleaseRE + `))?(?:\+(` + buildRE + `))?)?)?$`)
+
+// partialVersion is a parsed partial version; parts is the number of
+// leading numeric parts given, from 0 for "*" to 3 for a full version.
+type partialVersion struct {
+	v     semVersion
+	parts int
+}
+
+func parsePartial(s string) (partialVersion, error) {
+	m := partialRE.FindStringSubmatch(s)
+	if m == nil {
+		return partialVersion{}, fmt.Errorf("%q is not a version", s)
+	}
+	var p partialVersion
+	for i, dst := range []*uint64{&p.v.major, &p.v.minor, &p.v.patch} {
+		part := m[i+1]
+		if part == "" || strings.ContainsAny(part, "xX*") {
+			break
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return partialVersion{}, fmt.Errorf("%q is not a version: %w", s, err)
+		}
+		*dst = n
+		p.parts++
+	}
+	if p.parts < 3 && (m[4] != "" || m[5] != "") {
+		return partialVersion{}, fmt.Errorf("%q has wildcards and a pre-release or build", s)
+	}
+	p.v.prerelease, p.v.build = m[4], m[5]
+	return p, nil
+}
+
+// next returns the lowest version above every version p covers. It must
+// not be called on "*".
+func (p partialVersion) next() semVersion {
+	switch p.parts {
+	case 1:
+		return semVersion{major: p.v.major + 1}
+	case 2:
+		return semVersion{major: p.v.major, minor: p.v.minor + 1}
+	}
+	return semVersion{major: p.v.major, minor: p.v.minor, patch: p.v.patch + 1}
+}
+
+// operators in the order they must be tried, longest first.
+var operators = []string{">=", "<=", "!=", ">", "<", "=", "~", "^"}
+
+// parseVersionRange parses a --version-constraint.
+func parseVersionRange(s string) (*versionRange, error) {
+	c := &versionRange{}
+	for alt := range strings.SplitSeq(s, "||") {
+		cmps, err := parseAlternative(alt)
+		if err != nil {
+			return nil, err
+		}
+		c.alternatives = append(c.alternatives, cmps)
+	}
+	return c, nil
+}
+
+func parseAlternative(s string) ([]comparison, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == '\t' || r == ',' })
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty alternative in %q", strings.TrimSpace(s))
+	}
+	// A hyphen range is exactly "A - B".
+	if len(fields) == 3 && fields[1] == "-" {
+		lo, err := parsePartial(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		hi, err := parsePartial(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		cmps := []comparison{{op: ">=", v: lo.v}}
+		switch hi.parts {
+		case 0:
+		case 3:
+			cmps = append(cmps, comparison{op: "<=", v: hi.v})
+		default:
+			cmps = append(cmps, comparison{op: "<", v: hi.next()})
+		}
+		return cmps, nil
+	}
+	var cmps []comparison
+	for i := 0; i < len(fields); i++ {
+		term := fields[i]
+		// Allow a space between the operator and the version, as in ">= 1.23".
+		if slices.Contains(operators, term) && i+1 < len(fields) {
+			i++
+			term += fields[i]
+		}
+		expanded, err := parseComparison(term)
+		if err != nil {
+			return nil, err
+		}
+		cmps = append(cmps, expanded...)
+	}
+	return cmps, nil
+}
+
+// parseComparison expands one operator and version into the comparisons
+// it stands for.
+func parseComparison(term string) ([]comparison, error) {
+	op := ""
+	for _, o := range operators {
+		if strings.HasPrefix(term, o) {
+			op = o
+			break
+		}
+	}
+	p, err := parsePartial(term[len(op):])
+	if err != nil {
+		return nil, err
+	}
+	v := p.v
+	if p.parts == 0 {
+		switch op {
+		case "", "=", ">=", "<=", "~", "^":
+			return []comparison{{op: ">=", v: semVersion{}}}, nil
+		}
+		return nil, fmt.Errorf("%q matches no version", term)
+	}
+	if p.parts == 3 {
+		switch op {
+		case "", "=":
+			return []comparison{{op: "=", v: v}}, nil
+		case "!=", ">", ">=", "<", "<=":
+			return []comparison{{op: op, v: v}}, nil
+		}
+	}
+	switch op {
+	case "", "=":
+		return []comparison{{op: ">=", v: v}, {op: "<", v: p.next()}}, nil
+	case "!=":
+		next := p.next()
+		return []comparison{{op: "!=", v: v, upper: &next}}, nil
+	case ">":
+		return []comparison{{op: ">=", v: p.next()}}, nil
+	case ">=", "<":
+		return []comparison{{op: op, v: v}}, nil
+	case "<=":
+		return []comparison{{op: "<", v: p.next()}}, nil
+	case "~":
+		// ~1 is 1.x; ~1.2 and ~1.2.3 allow patch releases only.
+		upper := semVersion{major: v.major, minor: v.minor + 1}
+		if p.parts == 1 {
+			upper = semVersion{major: v.major + 1}
+		}
+		return []comparison{{op: ">=", v: v}, {op: "<", v: upper}}, nil
+	case "^":
+		// The leftmost non-zero part given may not change.
+		var upper semVersion
+		switch {
+		case v.major > 0 || p.parts == 1:
+			upper = semVersion{major: v.major + 1}
+		case v.minor > 0 || p.parts == 2:
+			upper = semVersion{minor: v.minor + 1}
+		default:
+			upper = semVersion{patch: v.patch + 1}
+		}
+		return []comparison{{op: ">=", v: v}, {op: "<", v: upper}}, nil
+	}
+	return nil, fmt.Errorf("invalid comparison %q", term)
+}
+
+// matches reports whether v passes the comparison.
+func (c comparison) matches(v semVersion) bool {
+	n := v.compare(c.v)
+	switch c.op {
+	case "=":
+		return n == 0
+	case "!=":
+		if c.upper != nil {
+			return n < 0 || v.compare(*c.upper) >= 0
+		}
+		return n != 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	}
+	return false
+}
+
+// check reports whether v satisfies the range.
+func (c *versionRange) check(v semVersion) bool {
+	for _, cmps := range c.alternatives {
+		if allowedIn(cmps, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func allowedIn(cmps []comparison, v semVersion) bool {
+	prereleaseNamed := v.prerelease == ""
+	for _, c := range cmps {
+		if !c.matches(v) {
+			return false
+		}
+		if c.v.prerelease != "" && c.v.major == v.major && c.v.minor == v.minor && c.v.patch == v.patch {
+			prereleaseNamed = true
+		}
+	}
+	return prereleaseNamed
+}
@@ -0,0 +1,141 @@
+package scraper
+
+import (
+	"testing"
+
+	"go.uber.org/goleak"
+)
+
+func TestVersionRange(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{">=1.23.0 <1.25.0", "1.23.0", true},
+		{">=1.23.0 <1.25.0", "1.24.9", true},
+		{">=1.23.0 <1.25.0", "1.25.0", false},
+		{">=1.23.0 <1.25.0", "1.22.9", false},
+		{">=1.23.0, <1.25.0", "1.24.0", true},
+		{">= 1.23.0 < 1.25.0", "1.24.0", true},
+		{"v1.23.0", "1.23.0", true},
+		{"=1.23.0", "v1.23.0", true},
+		{"1.23.0", "1.23.0+deadbeef", true},
+
+		// Pre-releases only match comparisons that name one of the same
+		// MAJOR.MINOR.PATCH.
+		{">=1.22.0", "1.23.0-rc.1", false},
+		{">=1.23.0 <1.25.0", "1.25.0-rc.1", false},
+		{"<1.25.0", "1.25.0-rc.1", false},
+		{">=1.23.0-rc.1", "1.23.0-rc.2", true},
+		{">=1.23.0-rc.1", "1.23.0-rc.1", true},
+		{">=1.23.0-rc.1", "1.23.0-beta.1", false},
+		{">=1.23.0-rc.1", "1.23.0", true},
+		{">=1.23.0-rc.1", "1.24.0-rc.1", false},
+		{">=1.23.0-rc.1", "1.24.0", true},
+		{">1.23.0-rc.2 <1.24.0", "1.23.0-rc.10", true},
+		{">1.23.0-rc.2 <1.24.0", "1.23.0-rc.2", false},
+		{"1.23.0-rc.1", "1.23.0", false},
+		{"1.23.0-rc.1", "1.23.0-rc.1", true},
+		{"1.23.0-rc.1 || 1.22.x", "1.22.5", true},
+		{"1.22.x || >=1.23.0-rc.1 <1.24.0", "1.23.0-rc.3", true},
+		{"~1.23.0-rc.1", "1.23.0-rc.2", true},
+		{"~1.23.0-rc.1", "1.23.5", true},
+		{"^1.23.0-rc.1", "1.23.1-rc.1", false},
+		{"*", "1.23.0-rc.1", false},
+		{"*", "1.23.0", true},
+		{"1.23.0-custom.3-g1a2b3c4", "1.23.0-custom.3-g1a2b3c4", true},
+		{"!=1.23.0", "1.23.0-rc.1", false},
+
+		// Partial versions and wildcards.
+		{"1.22", "1.22.0", true},
+		{"1.22", "1.22.9", true},
+		{"1.22", "1.23.0", false},
+		{"1.22.x", "1.22.4", true},
+		{"1.22.*", "1.22.4", true},
+		{"1.X", "1.99.0", true},
+		{"1", "2.0.0", false},
+		{"x", "0.0.1", true},
+		{">1.22", "1.22.9", false},
+		{">1.22", "1.23.0", true},
+		{">1", "1.99.0", false},
+		{"<=1.22", "1.22.9", true},
+		{"<=1.22", "1.23.0", false},
+		{"<1.22", "1.21.9", true},
+		{"<1.22", "1.22.0", false},
+		{">=1.22", "1.22.0", true},
+		{"!=1.22", "1.22.3", false},
+		{"!=1.22", "1.23.0", true},
+		{"!=1.22", "1.21.0", true},
+		{"!=1.22.3", "1.22.4", true},
+
+		// Tilde and caret.
+		{"~1.22", "1.22.0", true},
+		{"~1.22", "1.23.0", false},
+		{"~1.22.3", "1.22.2", false},
+		{"~1.22.3", "1.22.9", true},
+		{"~1.22.3", "1.23.0", false},
+		{"~1", "1.99.0", true},
+		{"^1.22.3", "1.99.0", true},
+		{"^1.22.3", "2.0.0", false},
+		{"^1.22.3", "1.22.2", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"^0.0", "0.0.9", true},
+		{"^0.0", "0.1.0", false},
+		{"^0", "0.9.0", true},
+		{"^0", "1.0.0", false},
+
+		// Hyphen ranges are inclusive; a partial upper end covers its range.
+		{"1.22.0 - 1.24.2", "1.24.2", true},
+		{"1.22.0 - 1.24.2", "1.24.3", false},
+		{"1.22 - 1.24", "1.24.9", true},
+		{"1.22 - 1.24", "1.25.0", false},
+		{"1.22 - 1.24", "1.21.9", false},
+		{"1.22 - *", "9.0.0", true},
+
+		// Versions that are not full semver never satisfy a range.
+		{"*", "1.23", false},
+		{">=1.0.0", "unknown", false},
+		{">=1.0.0", "", false},
+	}
+	for _, tt := range tests {
+		r, err := parseVersionRange(tt.constraint)
+		if err != nil {
+			t.Errorf("parseVersionRange(%q): %v", tt.constraint, err)
+			continue
+		}
+		sv, ok := parseSemver(tt.version)
+		if got := ok && r.check(sv); got != tt.want {
+			t.Errorf("%q satisfies %q = %v, want %v", tt.version, tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestVersionRangeInvalid(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	for _, s := range []string{
+		"",
+		"||",
+		">=1.22.0 ||",
+		"abc",
+		">=",
+		"=>1.22.0",
+		">>1.22.0",
+		"1.22.0.1",
+		"01.22.0",
+		"1.22-rc.1",
+		"1.x-rc.1",
+		">*",
+		"<*",
+		"!=*",
+		"1.22.0 -",
+		"1.22.0 - 1.24.0 - 1.25.0",
+	} {
+		if _, err := parseVersionRange(s); err == nil {
+			t.Errorf("parseVersionRange(%q) succeeded, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package scraper
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type panel map[string]any
+
+func gridPos(x, y, w, h int) map[string]int {
+	return map[string]int{"x": x, "y": y, "w": w, "h": h}
+}
+
+func target(expr, legend string, extra ...map[string]any) map[string]any {
+	t := map[string]any{
+		"datasource":   map[string]string{"type": "prometheus", "uid": "${datasource}"},
+		"expr":         expr,
+		"legendFormat": legend,
+		"refId":        "A",
+	}
+	for _, e := range extra {
+		for k, v := range e {
+			t[k] = v
+		}
+	}
+	return t
+}
+
+// dashboard builds a Grafana (9+, schema 39) dashboard for metrics named
+// with prefix.
+func dashboard(p string) map[string]any {
+	ds := map[string]string{"type": "prometheus", "uid": "${datasource}"}
+	sel := `{address=~"$address"}`
+	panels := []panel{
+		{
+			"id": 1, "type": "table", "title": "Server versions", "datasource": ds,
+			"gridPos": gridPos(0, 0, 24, 8),
+			"targets": []any{target(
+				p+`_server_version_info`+sel+` * on (address) group_left (cluster_name) `+p+`_cluster_info{cluster_name=~"$cluster_name"}`,
+				"", map[string]any{"format": "table", "instant": true},
+			)},
+			"transformations": []any{map[string]any{
+				"id": "organize",
+				"options": map[string]any{
+					"excludeByName": map[string]bool{"Time": true, "Value": true, "__name__": true},
+				},
+			}},
+		},
+		{
+			"id": 2, "type": "timeseries", "title": "Scrape latency (p95)", "datasource": ds,
+			"gridPos":     gridPos(0, 8, 12, 8),
+			"fieldConfig": map[string]any{"defaults": map[string]any{"unit": "s"}},
+			"targets": []any{target(
+				`histogram_quantile(0.95, sum by (address, le) (rate(`+p+`_exporter_scrape_duration_seconds_bucket`+sel+`[$__rate_interval])))`,
+				"{{address}}",
+			)},
+		},
+		{
+			"id": 3, "type": "timeseries", "title": "Scrape errors", "datasource": ds,
+			"gridPos":     gridPos(12, 8, 12, 8),
+			"fieldConfig": map[string]any{"defaults": map[string]any{"unit": "ops"}},
+			"targets": []any{target(
+				`sum by (address, error_type) (rate(`+p+`_exporter_scrape_errors_total`+sel+`[$__rate_interval]))`,
+				"{{address}} {{error_type}}",
+			)},
+		},
+	}
+
+	variables := []any{
+		map[string]any{
+			"name": "datasource", "label": "Data source", "type": "datasource", "query": "prometheus",
+		},
+		map[string]any{
+			"name": "address", "label": "Address", "type": "query", "datasource": ds,
+			"query":      map[string]string{"query": "label_values(" + p + "_server_version_info, address)", "refId": "address"},
+			"definition": "label_values(" + p + "_server_version_info, address)",
+			"includeAll": true, "multi": true, "refresh": 2,
+			"current": map[string]any{"text": "All", "value": "$__all"},
+		},
+		map[string]any{
+			"name": "cluster_name", "label": "Cluster", "type": "query", "datasource": ds,
+			"query":      map[string]string{"query": "label_values(" + p + "_cluster_info, cluster_name)", "refId": "cluster_name"},
+			"definition": "label_values(" + p + "_cluster_info, cluster_name)",
+			"includeAll": true, "multi": true, "refresh": 2, "allValue": ".*",
+			"current": map[string]any{"text": "All", "value": "$__all"},
+		},
+	}
+
+	annotations := []any{map[string]any{
+		"name": "Version changes", "datasource": ds, "enable": true, "iconColor": "orange",
+		"expr":        `changes(` + p + `_server_version_changes_total` + sel + `[2m]) > 0`,
+		"step":        "1m",
+		"titleFormat": "Version change",
+		"textFormat":  "{{address}}",
+	}}
+
+	return map[string]any{
+		"title":         "Temporal server versions",
+		"uid":           "temporal-version-exporter",
+		"tags":          []string{"temporal"},
+		"schemaVersion": 39,
+		"editable":      true,
+		"refresh":       "1m",
+		"time":          map[string]string{"from": "now-24h", "to": "now"},
+		"templating":    map[string]any{"list": variables},
+		"annotations":   map[string]any{"list": annotations},
+		"panels":        panels,
+	}
+}
+
+// WriteDashboard renders the Grafana dashboard JSON for metrics named
+// with prefix.
+func WriteDashboard(w io.Writer, prefix string) error {
+	if err := validateMetricPrefix(prefix); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dashboard(prefix))
+}
@@ -0,0 +1,54 @@
+package scraper
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// isSRVName reports whether addr is an SRV record name such as
+// _temporal._tcp.example.com rather than a host:port.
+func isSRVName(addr string) bool {
+	return strings.HasPrefix(addr, "_")
+}
+
+// lookupSRVTargets resolves an SRV record to host:port addresses, ordered
+// by priority and weight as returned by the resolver.
+func lookupSRVTargets(ctx context.Context, name string) ([]string, error) {
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, 0, len(records))
+	for _, rec := range records {
+		host := strings.TrimSuffix(rec.Target, ".")
+		addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(int(rec.Port))))
+	}
+	return addrs, nil
+}
+
+// runSRVDiscovery monitors the targets of the SRV record name until ctx is
+// cancelled. A failed lookup keeps the previous targets.
+func (s *Scraper) runSRVDiscovery(ctx context.Context, name string) {
+	for {
+		lookupCtx, cancel := context.WithTimeout(ctx, s.cfg.DialTimeout)
+		addrs, err := lookupSRVTargets(lookupCtx, name)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.Error("SRV lookup failed, keeping current targets", "name", name, "err", err)
+		} else {
+			s.setTargets(addrs)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.cfg.DNSRefreshInterval):
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dnsMetrics are the series of the DNS resolution of the targets.
+type dnsMetrics struct {
+	dnsDuration *prometheus.HistogramVec
+	dnsFailures *prometheus.CounterVec
+	dnsRecords  *prometheus.GaugeVec
+}
+
+func newDNSMetrics() dnsMetrics {
+	return dnsMetrics{
+		dnsDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "exporter_dns_lookup_duration_seconds",
+				Help:    "Duration of the A/AAAA lookup of the target's host name, made every cycle. Absent for IP address targets",
+				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 5},
+			},
+			[]string{"address"},
+		),
+		dnsFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "exporter_dns_lookup_failures_total",
+				Help: "Number of failed lookups of the target's host name",
+			},
+			[]string{"address"},
+		),
+		dnsRecords: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "exporter_dns_lookup_records",
+				Help: "Number of A and AAAA records returned by the last successful lookup of the target's host name",
+			},
+			[]string{"address"},
+		),
+	}
+}
+
+// lookupTarget resolves the host name of addr and exports how long it took
+// and what it returned, so that resolver problems can be told apart from
+// Temporal ones. IP address targets are skipped.
+func (s *Scraper) lookupTarget(ctx context.Context, addr string) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return
+	}
+	start := time.Now()
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	s.dnsDuration.WithLabelValues(addr).Observe(time.Since(start).Seconds())
+
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+	if err != nil {
+		s.dnsFailures.WithLabelValues(addr).Inc()
+		slog.Warn("DNS lookup failed", "address", addr, "host", host, "err", err)
+		return
+	}
+	s.dnsRecords.WithLabelValues(addr).Set(float64(len(ips)))
+}
@@ -0,0 +1,633 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	v1 "go.temporal.io/api/workflowservice/v1"
+	"golang.org/x/mod/semver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Build information, set at link time with
+// -ldflags "-X temporal-version-exporter/scraper.buildVersion=..." and likewise
+// buildRevision and buildDate.
+var (
+	buildVersion  = "dev"
+	buildRevision = "dev"
+	buildDate     = "dev"
+)
+
+// VersionString describes the build: its version, revision, date and Go
+// version.
+func VersionString() string {
+	return fmt.Sprintf("temporal-version-exporter version %s (revision %s, built %s, %s)",
+		buildVersion, buildRevision, buildDate, runtime.Version())
+}
+
+func userAgent() string {
+	return fmt.Sprintf("temporal-version-exporter/%s (%s)", buildVersion, buildRevision)
+}
+
+// Metric names below omit the "temporal_" prefix; it is supplied by
+// --metric-prefix when the metrics are registered.
+
+// coreMetrics are the series of every refresh.
+type coreMetrics struct {
+	versionGauge     *prometheus.GaugeVec
+	unknownGauge     *prometheus.GaugeVec
+	upGauge          *prometheus.GaugeVec
+	majorGauge       *prometheus.GaugeVec
+	minorGauge       *prometheus.GaugeVec
+	patchGauge       *prometheus.GaugeVec
+	numberGauge      *prometheus.GaugeVec
+	parseFailures    *prometheus.CounterVec
+	versionChanges   *prometheus.CounterVec
+	lastChangeGauge  *prometheus.GaugeVec
+	lastSuccessGauge *prometheus.GaugeVec
+	scrapeErrors     *prometheus.CounterVec
+	scrapeDuration   *prometheus.HistogramVec
+	rollbacks        *prometheus.CounterVec
+	buildInfoGauge   *prometheus.GaugeVec
+}
+
+func newCoreMetrics() coreMetrics {
+	return coreMetrics{
+		versionGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_info",
+				Help: "Server version detected on the target, carried in the version label; the value is always 1",
+			},
+			[]string{"address", "version", "prerelease", "revision", "build", "source"},
+		),
+		unknownGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_unknown",
+				Help: "Whether the last refresh of the target failed to determine its server version, as 1 or 0; present for every configured target from startup",
+			},
+			[]string{"address"},
+		),
+		upGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "exporter_up",
+				Help: "Whether the most recent refresh of the target succeeded, as 1 or 0",
+			},
+			[]string{"address"},
+		),
+		majorGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_major",
+				Help: "Major component of the detected server version. Absent if the version is not semver",
+			},
+			[]string{"address"},
+		),
+		minorGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_minor",
+				Help: "Minor component of the detected server version. Absent if the version is not semver",
+			},
+			[]string{"address"},
+		),
+		patchGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_patch",
+				Help: "Patch component of the detected server version. Absent if the version is not semver",
+			},
+			[]string{"address"},
+		),
+		numberGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_number",
+				Help: "Detected server version encoded as major*1e6 + minor*1e3 + patch, minus 0.5 for pre-releases. Absent if the version is not semver",
+			},
+			[]string{"address"},
+		),
+		parseFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "server_version_parse_failures_total",
+				Help: "Number of refreshes whose detected version could not be parsed as semver",
+			},
+			[]string{"address"},
+		),
+		versionChanges: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "server_version_changes_total",
+				Help: "Number of times the detected server version changed since the exporter started",
+			},
+			[]string{"address"},
+		),
+		lastChangeGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_last_change_timestamp_seconds",
+				Help: "Unix time at which the exporter last saw the server version change",
+			},
+			[]string{"address"},
+		),
+		lastSuccessGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "exporter_last_successful_scrape_timestamp_seconds",
+				Help: "Unix time of the last refresh of the target that determined its version",
+			},
+			[]string{"address"},
+		),
+		scrapeErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "exporter_scrape_errors_total",
+				Help: "Number of refreshes that failed to determine the version, by error type (dial, no_version)",
+			},
+			[]string{"address", "error_type"},
+		),
+		scrapeDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "exporter_scrape_duration_seconds",
+				Help:    "Duration of a complete refresh of the target, including dialing and retries",
+				Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+			},
+			[]string{"address"},
+		),
+		rollbacks: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "server_version_rollback_total",
+				Help: "Number of times the detected server version changed to a semantically older version",
+			},
+			[]string{"address"},
+		),
+		buildInfoGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "version_exporter_build_info",
+				Help: "Build of the running exporter, carried in the version, revision and goversion labels; the value is always 1",
+			},
+			[]string{"version", "revision", "goversion"},
+		),
+	}
+}
+
+// targetState is what the exporter remembers about a target between
+// refreshes. It is guarded by Scraper.metricsMu.
+type targetState struct {
+	// version is the last detected version. It is kept while the target is
+	// unknown so that recovering to the same version is not a change.
+	version string
+	// failures counts consecutive failed refreshes.
+	failures int
+	// succeeded is set once a refresh has detected a version. Versions
+	// restored from Redis do not set it.
+	succeeded bool
+	// source is the RPC that supplied version and detectedAt when it last
+	// did; detectedAt is zero for a version restored from Redis.
+	source     string
+	detectedAt time.Time
+	// lastError is the error_type of the latest refresh if it failed.
+	lastError string
+	// lastDuration is how long the latest refresh took, and nextRefresh
+	// when the next one is due.
+	lastDuration time.Duration
+	nextRefresh  time.Time
+	// clusterName and clusterID are the last identity GetClusterInfo
+	// reported.
+	clusterName, clusterID string
+	// failover is nil until GetClusterInfo first succeeds.
+	failover *failoverVersions
+	// sysInfoUnsupportedAt is when GetSystemInfo last answered
+	// Unimplemented; zero if it has not, or if the version has changed
+	// since.
+	sysInfoUnsupportedAt time.Time
+	// history is the versions detected so far.
+	history versionHistory
+	// paged is set while a PagerDuty alert is open for the target.
+	paged bool
+	// caFingerprint is the SHA-256 of the CA certificate of the last TLS
+	// handshake, hex encoded.
+	caFingerprint string
+}
+
+// stateFor returns the state for addr. It must be called with s.metricsMu
+// held.
+func (s *Scraper) stateFor(addr string) *targetState {
+	st, ok := s.targetStates[addr]
+	if !ok {
+		st = &targetState{}
+		s.targetStates[addr] = st
+	}
+	return st
+}
+
+// lockedCollector collects the wrapped collectors while holding mu.
+type lockedCollector struct {
+	mu         *sync.RWMutex
+	collectors []prometheus.Collector
+}
+
+func (c lockedCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, col := range c.collectors {
+		col.Describe(ch)
+	}
+}
+
+func (c lockedCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, col := range c.collectors {
+		col.Collect(ch)
+	}
+}
+
+// RegisterMetrics registers the Scraper's metrics on reg, named with its
+// metric prefix and carrying its extra labels. New registers them on the
+// Scraper's own registry; RegisterMetrics lets them be embedded in
+// another program's registry as well.
+func (s *Scraper) RegisterMetrics(reg prometheus.Registerer) error {
+	if err := validateMetricPrefix(s.cfg.MetricPrefix); err != nil {
+		return err
+	}
+	return s.registerMetrics(prometheus.WrapRegistererWithPrefix(s.cfg.MetricPrefix+"_", prometheus.WrapRegistererWith(s.cfg.ExtraLabels, reg)))
+}
+
+// perTargetMetrics returns every metric vector that has an address label,
+// so that a removed target's series can be deleted.
+func (s *Scraper) perTargetMetrics() []interface {
+	DeletePartialMatch(prometheus.Labels) int
+} {
+	return []interface {
+		DeletePartialMatch(prometheus.Labels) int
+	}{
+		s.versionGauge, s.unknownGauge, s.upGauge, s.capabilityGauge,
+		s.majorGauge, s.minorGauge, s.patchGauge, s.numberGauge, s.parseFailures,
+		s.connStateGauge, s.versionChanges, s.lastChangeGauge, s.staleGauge,
+		s.connTransitions, s.rpcDuration, s.clusterInfoGauge, s.rollbacks, s.shardCountGauge,
+		s.persistenceInfoGauge, s.initialFailoverGauge, s.failoverIncrementGauge, s.lastSuccessGauge,
+		s.scrapeErrors, s.scrapeDuration, s.supportedClientGauge, s.sysInfoUnsupportedGauge,
+		s.versionAgeGauge, s.versionAgeUnknown, s.versionsBehindGauge,
+		s.belowMinimumGauge, s.minimumUncomparable, s.mismatchGauge, s.constraintGauge,
+		s.effectiveIntervalGauge, s.minorsBehindGauge, s.supportedGauge, s.tlsExpiryGauge,
+		s.endpointInfoGauge, s.healthyGauge, s.notServingTotal, s.dnsDuration, s.dnsFailures, s.dnsRecords,
+		s.extractionSourceGauge,
+	}
+}
+
+// registerMetrics registers the Scraper's own metrics on reg.
+func (s *Scraper) registerMetrics(reg prometheus.Registerer) error {
+	if err := reg.Register(lockedCollector{&s.metricsMu, []prometheus.Collector{
+		s.versionGauge, s.unknownGauge, s.upGauge, s.capabilityGauge,
+		s.majorGauge, s.minorGauge, s.patchGauge, s.numberGauge, s.parseFailures,
+		s.connStateGauge, s.versionChanges, s.lastChangeGauge, s.staleGauge,
+		s.clusterInfoGauge, s.rollbacks, s.shardCountGauge, s.persistenceInfoGauge,
+		s.initialFailoverGauge, s.failoverIncrementGauge, s.lastSuccessGauge, s.scrapeErrors,
+		s.supportedClientGauge, s.sysInfoUnsupportedGauge,
+		s.versionAgeGauge, s.versionAgeUnknown, s.versionsBehindGauge, s.latestReleaseGauge,
+		s.belowMinimumGauge, s.minimumUncomparable, s.mismatchGauge, s.constraintGauge,
+		s.effectiveIntervalGauge, s.minorsBehindGauge, s.supportedGauge, s.tlsExpiryGauge,
+		s.endpointInfoGauge, s.healthyGauge, s.notServingTotal, s.dnsFailures, s.dnsRecords,
+		s.extractionSourceGauge,
+	}}); err != nil {
+		return err
+	}
+	for _, c := range []prometheus.Collector{s.buildInfoGauge, s.connTransitions, s.rpcDuration, s.webhookSends, s.scrapeDuration, s.latestCheckErrors, s.pagerDutyEvents, s.remoteWriteBytes, s.remoteWriteErrors, s.dnsDuration, s.authFailures, s.serverTLSEnabled, s.httpRequests, s.httpDuration, s.httpResponseSize, s.rateLimited, s.textfileLastWrite, s.textfileWriteErrors} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	s.buildInfoGauge.WithLabelValues(buildVersion, buildRevision, runtime.Version()).Set(1)
+	return nil
+}
+
+// refresh queries addr once and returns what it found, without updating
+// any series; record applies the result. A refresh interrupted by the
+// cancellation of ctx returns ctx.Err() in Err.
+func (s *Scraper) refresh(ctx context.Context, addr string, cfg connConfig) ScrapeResult {
+	start := time.Now()
+	var res ScrapeResult
+	// The lookup is made every cycle, not only when addr is redialed.
+	lookupCtx, cancel := context.WithTimeout(ctx, s.cfg.DialTimeout)
+	s.lookupTarget(lookupCtx, addr)
+	cancel()
+	conn, err := s.dial(ctx, addr, cfg)
+	if err != nil {
+		res.Err, res.ErrorType = fmt.Errorf("grpc dial: %w", err), "dial"
+	} else if res, err = s.query(ctx, addr, conn); err != nil {
+		res.Err, res.ErrorType = err, "no_version"
+	}
+	res.Address = addr
+	res.Duration = time.Since(start)
+	if ctx.Err() != nil {
+		res.Err, res.ErrorType = ctx.Err(), ""
+	}
+	return res
+}
+
+// query makes the RPCs of a refresh on conn. It fails with errNoVersion if
+// neither response holds a version.
+func (s *Scraper) query(ctx context.Context, addr string, conn *grpc.ClientConn) (ScrapeResult, error) {
+	res := ScrapeResult{connState: conn.GetState()}
+	var err error
+
+	if s.cfg.HealthProbe {
+		res.health = probeHealth(ctx, addr, conn)
+	}
+
+	client := v1.NewWorkflowServiceClient(conn)
+
+	// Try GetSystemInfo (preferred); fallback to GetClusterInfo
+
+	s.metricsMu.Lock()
+	res.skippedSysInfo = s.skipSystemInfo(s.stateFor(addr))
+	s.metricsMu.Unlock()
+
+	// Servers that predate GetSystemInfo have none of the capabilities.
+	// Once one answers Unimplemented it is not asked again until
+	// --system-info-recheck-interval passes or its version changes.
+	res.sysUnimplemented = res.skippedSysInfo
+	if !res.skippedSysInfo {
+		res.sysResp, err = client.GetSystemInfo(ctx, &v1.GetSystemInfoRequest{})
+		res.sysUnimplemented = status.Code(err) == codes.Unimplemented
+		if err != nil {
+			res.sysResp = nil
+		}
+	}
+	res.Version, res.extraction = s.extractor.ExtractFromSystemInfo(res.sysResp)
+	res.Source = "system_info"
+
+	// GetClusterInfo is called every cycle for the cluster identity, and
+	// doubles as the version fallback.
+	res.clusResp, err = client.GetClusterInfo(ctx, &v1.GetClusterInfoRequest{})
+	if err != nil {
+		res.clusResp = nil
+	}
+	res.ClusterName = res.clusResp.GetClusterName()
+	if res.Version == "" {
+		res.Version, res.extraction = s.extractor.ExtractFromClusterInfo(res.clusResp)
+		res.Source = "cluster_info"
+	}
+	if res.Version == "" {
+		res.Source = ""
+		return res, errNoVersion
+	}
+	return res, nil
+}
+
+// errNoVersion is the Err of a refresh whose responses held no version.
+var errNoVersion = errors.New("version not found in responses")
+
+// record logs res and updates the series, the state and the Redis cache of
+// its target. A refresh interrupted by the cancellation of ctx is dropped.
+func (s *Scraper) record(ctx context.Context, res ScrapeResult, cfg connConfig) {
+	if ctx.Err() != nil {
+		return
+	}
+	addr := res.Address
+	s.metrics.ObserveScrapeDuration(addr, res.Duration)
+
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+
+	s.stateFor(addr).lastDuration = res.Duration
+	if res.ErrorType == "dial" {
+		s.shardCountGauge.DeleteLabelValues(addr)
+		s.markUnknown(addr, "dial")
+		slog.Error("refresh failed", "address", addr, "err", res.Err)
+		return
+	}
+	s.setConnState(addr, res.connState)
+
+	st := s.stateFor(addr)
+	s.updateClusterInfo(addr, st, res.clusResp)
+	if s.cfg.HealthProbe {
+		s.setHealth(addr, res.health)
+	}
+
+	if res.sysResp != nil || res.sysUnimplemented {
+		s.setCapabilities(addr, res.sysResp.GetCapabilities())
+	}
+	switch {
+	case res.sysResp != nil:
+		st.sysInfoUnsupportedAt = time.Time{}
+		s.sysInfoUnsupportedGauge.WithLabelValues(addr).Set(0)
+	case res.sysUnimplemented && !res.skippedSysInfo:
+		if st.sysInfoUnsupportedAt.IsZero() {
+			slog.Info("GetSystemInfo is not implemented by the server; using GetClusterInfo", "address", addr)
+		}
+		st.sysInfoUnsupportedAt = time.Now()
+		s.sysInfoUnsupportedGauge.WithLabelValues(addr).Set(1)
+	}
+
+	s.setExtractionSource(addr, res.extraction)
+	version := res.Version
+	if version == "" {
+		s.markUnknown(addr, "no_version")
+		slog.Warn("version not found in responses", "address", addr)
+		return
+	}
+
+	if st.version != "" && st.version != version {
+		s.versionChanges.WithLabelValues(addr).Inc()
+		s.lastChangeGauge.WithLabelValues(addr).SetToCurrentTime()
+		slog.Info("temporal version changed", "address", addr, "old_version", st.version, "new_version", version)
+		rollback := s.checkRollback(addr, st.version, version)
+		s.notifyVersionChange(addr, st.clusterName, st.version, version)
+		s.auditVersionChange(addr, st.clusterName, st.version, version, rollback)
+		// The server may have been upgraded to one that has GetSystemInfo.
+		st.sysInfoUnsupportedAt = time.Time{}
+	}
+	if st.version == "" {
+		s.auditVersionChange(addr, st.clusterName, "", version, false)
+	}
+	st.version = version
+	st.history.observe(version, time.Now(), s.cfg.VersionHistorySize)
+	st.succeeded = true
+	st.source, st.detectedAt, st.lastError = res.Source, time.Now(), ""
+	s.staleSuccess(addr, st)
+	s.pagerDutySuccess(addr, st)
+
+	if !s.exportVersion(addr, version, res.Source) {
+		s.parseFailures.WithLabelValues(addr).Inc()
+	}
+	s.metrics.ClearUnknown(addr)
+	s.setEndpointInfo(addr, st, cfg)
+	s.lastSuccessGauge.WithLabelValues(addr).SetToCurrentTime()
+	slog.Info("detected temporal version", "address", addr, "version", version)
+	if s.redisClient != nil {
+		s.bg.Go(func() { s.storeVersion(ctx, addr, version, 10*s.cfg.ScrapeInterval) })
+	}
+}
+
+// exportVersion sets every series derived from addr's version and reports
+// whether the version is semver. It must be called with s.metricsMu held.
+func (s *Scraper) exportVersion(addr, version, source string) bool {
+	sv, ok := parseSemver(version)
+	if ok {
+		s.majorGauge.WithLabelValues(addr).Set(float64(sv.major))
+		s.minorGauge.WithLabelValues(addr).Set(float64(sv.minor))
+		s.patchGauge.WithLabelValues(addr).Set(float64(sv.patch))
+		s.numberGauge.WithLabelValues(addr).Set(sv.number())
+	} else {
+		s.majorGauge.DeleteLabelValues(addr)
+		s.minorGauge.DeleteLabelValues(addr)
+		s.patchGauge.DeleteLabelValues(addr)
+		s.numberGauge.DeleteLabelValues(addr)
+	}
+	s.setVersionAge(addr, version)
+	s.setVersionsBehind(addr, version)
+	s.checkMinVersion(addr, version)
+	s.checkExpectedVersion(addr, version)
+	s.checkConstraint(addr, version)
+	s.setSupportWindow(addr, version)
+
+	clean, prerelease, revision, build := splitVersion(version)
+	s.metrics.SetVersion(addr, clean, prerelease, revision, build, source)
+	return ok
+}
+
+// checkRollback counts and logs a change from oldVersion to an older
+// newVersion, and reports whether it was one. Versions that are not semver
+// are never treated as rollbacks. It must be called with s.metricsMu held.
+func (s *Scraper) checkRollback(addr, oldVersion, newVersion string) bool {
+	oldSV, ok1 := parseSemver(oldVersion)
+	newSV, ok2 := parseSemver(newVersion)
+	if !ok1 || !ok2 || newSV.compare(oldSV) >= 0 {
+		return false
+	}
+	s.rollbacks.WithLabelValues(addr).Inc()
+	slog.Warn("temporal version rolled back", "address", addr, "old_version", oldVersion, "new_version", newVersion,
+		"investigate", fmt.Sprintf("temporal operator cluster describe --address %s", addr))
+	return true
+}
+
+// markUnknown must be called with s.metricsMu held.
+func (s *Scraper) markUnknown(addr, errorType string) {
+	s.metrics.IncrError(addr, errorType)
+	s.metrics.SetUnknown(addr)
+	s.checkMinVersion(addr, "")
+	s.checkExpectedVersion(addr, "")
+	s.checkConstraint(addr, "")
+	s.setSupportWindow(addr, "")
+	st := s.stateFor(addr)
+	st.lastError = errorType
+	s.staleFailure(addr, st)
+	s.pagerDutyFailure(addr, st)
+}
+
+// versionKeys are the keys a version is looked for after, in order, with
+// the extraction source reported when one of them matches.
+var versionKeys = []struct{ key, source string }{
+	{"server_version", "server_version_field"},
+	{"build_version", "build_version_key"},
+	{"version", "version_key"},
+	{"component_version", "component_version_key"},
+}
+
+// very small best-effort version extraction; adapt to your environment.
+// source names the path that found the version and is empty on failure.
+func extractVersionFromSystemInfo(s string) (version, source string) {
+	for _, k := range versionKeys {
+		if v := scanAfterKey(s, k.key); v != "" {
+			return v, k.source
+		}
+	}
+	// last-resort: attempt to find a semver-like token
+	for p := range strings.FieldsSeq(s) {
+		if looksLikeSemver(p) {
+			return p, "semver_scan"
+		}
+	}
+	return "", ""
+}
+func extractVersionFromClusterInfo(s string) (version, source string) {
+	return extractVersionFromSystemInfo(s)
+}
+
+// scanAfterKey returns the version-like value that follows key in s, as in
+// `server_version:"1.22.4"` or `version1.22.4`. Matching is ASCII
+// case-insensitive and only counts whole keys: the key must not be the end
+// of a longer word (`sdk_version`) or the start of one (`version_info`).
+// Each occurrence is tried in turn; one whose value is not version-like
+// (`version:"1.22.4garbage"`) is skipped. An empty key matches nothing.
+func scanAfterKey(s, key string) string {
+	if key == "" {
+		return ""
+	}
+	for from := 0; from < len(s); {
+		i := indexFold(s[from:], key)
+		if i < 0 {
+			return ""
+		}
+		start, end := from+i, from+i+len(key)
+		from = end
+		if start > 0 && isWordByte(s[start-1]) {
+			continue
+		}
+		if end < len(s) && isWordByte(s[end]) && !isDigit(s[end]) {
+			continue
+		}
+		rest := strings.TrimLeft(s[end:], " \t:=\"'")
+		n := strings.IndexFunc(rest, func(r rune) bool {
+			return !(r == '.' || r == '-' || r == '+' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'))
+		})
+		if n < 0 {
+			n = len(rest)
+		}
+		if token := rest[:n]; looksLikeSemver(token) {
+			return token
+		}
+	}
+	return ""
+}
+
+// indexFold is strings.Index with ASCII case folding. Unlike searching a
+// strings.ToLower copy, the index it returns is always valid in s.
+func indexFold(s, substr string) int {
+	if substr == "" {
+		return 0
+	}
+	first := toLowerASCII(substr[0])
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if toLowerASCII(s[i]) == first && strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func toLowerASCII(b byte) byte {
+	if b >= 'A' && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+
+func isDigit(b byte) bool { return b >= '0' && b <= '9' }
+
+func isWordByte(b byte) bool {
+	return b == '_' || isDigit(b) || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// looksLikeSemver reports whether s is a full MAJOR.MINOR.PATCH semantic
+// version, with or without a leading "v". Pre-release and build suffixes
+// are accepted ("1.23.0-rc.1" is true). Shorthands ("1", "1.23") are
+// rejected, as are dotted strings with more than three parts or leading
+// zeros ("127.0.0.1", "2024.01.01") and those whose major version has three
+// or more digits, which are address octets, years or timestamps rather
+// than server versions ("192.168.1", "2024.1.1").
+func looksLikeSemver(s string) bool {
+	s = strings.TrimPrefix(s, "v")
+	// Most tokens are words; rejecting them here avoids building v.
+	if s == "" || !isDigit(s[0]) {
+		return false
+	}
+	v := "v" + s
+	if !semver.IsValid(v) {
+		return false
+	}
+	core, _, _ := strings.Cut(v, "+")
+	core, _, _ = strings.Cut(core, "-")
+	return strings.Count(core, ".") == 2 && len(semver.Major(v)) <= 3
+}
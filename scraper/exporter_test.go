@@ -0,0 +1,318 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	versionpb "go.temporal.io/api/version/v1"
+	v1 "go.temporal.io/api/workflowservice/v1"
+	"go.uber.org/goleak"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// protoResponse is the text form of a GetSystemInfo or GetClusterInfo
+// response of a Temporal release, as the default extractor scans it.
+type protoResponse struct {
+	name    string
+	version string
+	text    string
+}
+
+// protoResponses returns the responses of Temporal 1.22, 1.23 and 1.24
+// frontends, with the capabilities and supported clients those releases
+// report.
+func protoResponses() []protoResponse {
+	caps122 := &v1.GetSystemInfoResponse_Capabilities{
+		SignalAndQueryHeader:            true,
+		InternalErrorDifferentiation:    true,
+		ActivityFailureIncludeHeartbeat: true,
+		SupportsSchedules:               true,
+		EncodedFailureAttributes:        true,
+		BuildIdBasedVersioning:          true,
+		UpsertMemo:                      true,
+		EagerWorkflowStart:              true,
+	}
+	caps123 := &v1.GetSystemInfoResponse_Capabilities{
+		SignalAndQueryHeader:            true,
+		InternalErrorDifferentiation:    true,
+		ActivityFailureIncludeHeartbeat: true,
+		SupportsSchedules:               true,
+		EncodedFailureAttributes:        true,
+		BuildIdBasedVersioning:          true,
+		UpsertMemo:                      true,
+		EagerWorkflowStart:              true,
+		SdkMetadata:                     true,
+		CountGroupByExecutionStatus:     true,
+	}
+	caps124 := &v1.GetSystemInfoResponse_Capabilities{
+		SignalAndQueryHeader:            true,
+		InternalErrorDifferentiation:    true,
+		ActivityFailureIncludeHeartbeat: true,
+		SupportsSchedules:               true,
+		EncodedFailureAttributes:        true,
+		BuildIdBasedVersioning:          true,
+		UpsertMemo:                      true,
+		EagerWorkflowStart:              true,
+		SdkMetadata:                     true,
+		CountGroupByExecutionStatus:     true,
+		Nexus:                           true,
+	}
+	var out []protoResponse
+	for _, r := range []struct {
+		version string
+		caps    *v1.GetSystemInfoResponse_Capabilities
+	}{
+		{"1.22.4", caps122},
+		{"1.23.0", caps123},
+		{"1.24.2", caps124},
+	} {
+		out = append(out,
+			protoResponse{"system info " + r.version, r.version, (&v1.GetSystemInfoResponse{ServerVersion: r.version, Capabilities: r.caps}).String()},
+			protoResponse{"cluster info " + r.version, r.version, clusterInfoResponse(r.version).String()},
+		)
+	}
+	return out
+}
+
+// clusterInfoResponse returns the GetClusterInfo response of a frontend
+// running version, including its release information.
+func clusterInfoResponse(version string) *v1.GetClusterInfoResponse {
+	released := timestamppb.New(time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC))
+	return &v1.GetClusterInfoResponse{
+		SupportedClients: map[string]string{
+			"temporal-cli":        "< 2.0.0",
+			"temporal-go":         ">=1.0.0 <2.0.0",
+			"temporal-java":       ">=1.0.0 <2.0.0",
+			"temporal-php":        ">=1.0.0 <2.0.0",
+			"temporal-server":     ">=1.0.0 <2.0.0",
+			"temporal-typescript": ">=1.0.0 <2.0.0",
+			"temporal-ui":         "< 3.0.0",
+		},
+		ServerVersion: version,
+		ClusterId:     "f3a5c1e2-7b4d-4c8e-9a21-6d0b3e5f8c47",
+		VersionInfo: &versionpb.VersionInfo{
+			Current:        &versionpb.ReleaseInfo{Version: version, ReleaseTime: released, Notes: "https://github.com/temporalio/temporal/releases/tag/v" + version},
+			Recommended:    &versionpb.ReleaseInfo{Version: "1.25.1", ReleaseTime: released},
+			Instructions:   "https://docs.temporal.io/self-hosted-guide/upgrade-server",
+			Alerts:         []*versionpb.Alert{{Message: "A newer release of the Temporal server is available", Severity: 3}},
+			LastUpdateTime: released,
+		},
+		ClusterName:              "active",
+		HistoryShardCount:        512,
+		PersistenceStore:         "postgres12_pgx",
+		VisibilityStore:          "elasticsearch",
+		InitialFailoverVersion:   1,
+		FailoverVersionIncrement: 10,
+	}
+}
+
+func TestLooksLikeSemver(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"1.23.0", true},
+		{"1.23", false},
+		// Pre-releases are versions: release candidates are deployed.
+		{"1.23.0-rc.1", true},
+		{"127.0.0.1", false},
+		{"2024.01.01", false},
+		{"", false},
+		{"abc", false},
+		{"1", false},
+		{"1.2.3.4", false},
+
+		{"v1.23.0", true},
+		{"v1.23", false},
+		{"vv1.23.0", false},
+		{"1.23.0-rc1", true},
+		{"1.23.0-alpha.beta.2", true},
+		{"1.23.0-01", false}, // leading zero in a numeric identifier
+		{"1.23.0-", false},
+		{"1.22.4+deadbeef", true},
+		{"1.23.0-rc.1+build.5", true},
+		{"1.22.4+", false},
+		{"1.22.4+dead..beef", false},
+		// Two-part versions take no suffix.
+		{"1.23-rc.1", false},
+		{"1.23+meta", false},
+		{"01.23.0", false},
+		{"1.023.0", false},
+		{"1.23.00", false},
+		{"0.0.0", true},
+		{"10.0.0.1", false},
+		{"192.168.1", false}, // an address prefix, not a version
+		{"::1", false},
+		// Unix timestamps, bare and with fractions or dates.
+		{"1700000000", false},
+		{"1700000000.123", false},
+		{"2024.1.1", false},
+		{"2024-01-01", false},
+		{"2024-01-01T12:00:00Z", false},
+		{".", false},
+		{"1.", false},
+		{".1.2", false},
+		{"1..2", false},
+		{"1.2.", false},
+		{"1.2.3 ", false},
+		{"v", false},
+	}
+	for _, tt := range tests {
+		if got := looksLikeSemver(tt.in); got != tt.want {
+			t.Errorf("looksLikeSemver(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestScanAfterKey(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	for _, r := range protoResponses() {
+		if got := scanAfterKey(r.text, "server_version"); got != r.version {
+			t.Errorf("%s: scanAfterKey(server_version) = %q, want %q", r.name, got, r.version)
+		}
+		if got, source := extractVersionFromSystemInfo(r.text); got != r.version || source != "server_version_field" {
+			t.Errorf("%s: extractVersionFromSystemInfo = %q, %q, want %q, server_version_field", r.name, got, source, r.version)
+		}
+	}
+
+	tests := []struct {
+		name string
+		s    string
+		key  string
+		want string
+	}{
+		{"proto field", `server_version:"1.22.4"`, "server_version", "1.22.4"},
+		{"upper case key", `SERVER_VERSION: "1.22.4"`, "server_version", "1.22.4"},
+		{"equals and single quotes", `version='1.23.1'`, "version", "1.23.1"},
+		{"no separator", "version1.22.4", "version", "1.22.4"},
+		{"pre-release", `server_version:"1.24.0-rc.2"`, "server_version", "1.24.0-rc.2"},
+		{"build metadata", `server_version:"1.22.4+deadbeef"`, "server_version", "1.22.4+deadbeef"},
+		{"first of several", `version:"1.22.4" version:"1.23.0"`, "version", "1.22.4"},
+		{"first version-like of several", `version:"latest" version:"1.23.0"`, "version", "1.23.0"},
+		{"suffix of another word", `sdk_version:"1.20.0"`, "version", ""},
+		{"suffix of another word, then whole", `sdk_version:"1.20.0" version:"1.23.0"`, "version", "1.23.0"},
+		{"prefix of another word", `version_info:{current:{version:"1.23.0"}}`, "version", "1.23.0"},
+		{"absent", `cluster_name:"active" history_shard_count:512`, "server_version", ""},
+		{"key at the end", `cluster_name:"active" server_version`, "server_version", ""},
+		{"garbage after the value", `server_version:"1.22.4garbage"`, "server_version", ""},
+		{"garbage, then a valid value", `version:"1.22.4garbage" version:"1.22.5"`, "version", "1.22.5"},
+		{"IP address", `version:"127.0.0.1"`, "version", ""},
+		{"empty key", `version:"1.22.4"`, "", ""},
+		{"empty string", "", "version", ""},
+	}
+	for _, tt := range tests {
+		if got := scanAfterKey(tt.s, tt.key); got != tt.want {
+			t.Errorf("%s: scanAfterKey(%q, %q) = %q, want %q", tt.name, tt.s, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestExtractVersionFromSystemInfo(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	tests := []struct {
+		s          string
+		want       string
+		wantSource string
+	}{
+		{`server_version:"1.23.0" build_version:"1.22.0"`, "1.23.0", "server_version_field"},
+		{`build_version:"1.22.0" version:"1.21.0"`, "1.22.0", "build_version_key"},
+		{`sdk_version:"1.20.0" version:"1.21.0"`, "1.21.0", "version_key"},
+		{`component_version:"1.20.3"`, "1.20.3", "component_version_key"},
+		{`notes:"see 1.24.1 for details"`, "1.24.1", "semver_scan"},
+		{`server_version:"latest" build_version:"1.22.0"`, "1.22.0", "build_version_key"},
+		{`cluster_name:"active" history_shard_count:512`, "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		got, source := extractVersionFromSystemInfo(tt.s)
+		if got != tt.want || source != tt.wantSource {
+			t.Errorf("extractVersionFromSystemInfo(%q) = %q, %q, want %q, %q", tt.s, got, source, tt.want, tt.wantSource)
+		}
+	}
+}
+
+func FuzzExtractVersionFromSystemInfo(f *testing.F) {
+	defer goleak.VerifyNone(f)
+	for _, r := range protoResponses() {
+		f.Add(r.text)
+	}
+	f.Add("")
+	f.Add(`server_version:"`)
+	f.Add("version version: version:v")
+	f.Fuzz(func(t *testing.T, s string) {
+		version, source := extractVersionFromSystemInfo(s)
+		if (version == "") != (source == "") {
+			t.Errorf("extractVersionFromSystemInfo(%q) = %q, %q: version and source must be set together", s, version, source)
+		}
+		if version != "" && !looksLikeSemver(version) {
+			t.Errorf("extractVersionFromSystemInfo(%q) = %q, which is not version-like", s, version)
+		}
+	})
+}
+
+func FuzzScanAfterKey(f *testing.F) {
+	defer goleak.VerifyNone(f)
+	for _, r := range protoResponses() {
+		for _, k := range versionKeys {
+			f.Add(r.text, k.key)
+		}
+	}
+	f.Add("", "")
+	f.Add("version", "version")
+	f.Add(`sdk_version:"1.2.3"`, "VERSION")
+	f.Fuzz(func(t *testing.T, s, key string) {
+		v := scanAfterKey(s, key)
+		if v != "" && !looksLikeSemver(v) {
+			t.Errorf("scanAfterKey(%q, %q) = %q, which is not version-like", s, key, v)
+		}
+	})
+}
+
+// benchmarkInput is the text form of a Temporal 1.23 GetClusterInfo
+// response, about 1KB: the largest response the extractor scans.
+var benchmarkInput = clusterInfoResponse("1.23.0").String()
+
+func TestExtractVersionFromSystemInfoAllocs(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	if n := testing.AllocsPerRun(100, func() { extractVersionFromSystemInfo(benchmarkInput) }); n != 0 {
+		t.Errorf("extractVersionFromSystemInfo made %v allocations, want 0", n)
+	}
+}
+
+func BenchmarkExtractVersionFromSystemInfo(b *testing.B) {
+	noVersion := clusterInfoResponse("")
+	noVersion.VersionInfo = nil
+	for _, bb := range []struct {
+		name  string
+		input string
+	}{
+		{"system info", (&v1.GetSystemInfoResponse{ServerVersion: "1.23.0"}).String()},
+		{"cluster info", benchmarkInput},
+		// Without a key, every field is scanned for a version.
+		{"no version", noVersion.String()},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				extractVersionFromSystemInfo(bb.input)
+			}
+		})
+	}
+}
+
+func BenchmarkScanAfterKey(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		scanAfterKey(benchmarkInput, "server_version")
+	}
+}
+
+func BenchmarkLooksLikeSemver(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		looksLikeSemver("1.23.0-rc.1+build.5")
+		looksLikeSemver("127.0.0.1")
+		looksLikeSemver("supported_clients")
+	}
+}
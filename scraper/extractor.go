@@ -0,0 +1,61 @@
+package scraper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "go.temporal.io/api/workflowservice/v1"
+)
+
+// extractionMetrics are the series of the version extraction.
+type extractionMetrics struct {
+	extractionSourceGauge *prometheus.GaugeVec
+}
+
+func newExtractionMetrics() extractionMetrics {
+	return extractionMetrics{
+		extractionSourceGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "exporter_version_extraction_source",
+				Help: "Set to 1 for how the version of the target was found in the RPC response, e.g. server_version_field or semver_scan",
+			},
+			[]string{"address", "source"},
+		),
+	}
+}
+
+// VersionExtractor finds the server version in the responses of the two
+// RPCs a refresh makes. source names how the version was found, such as the
+// field it was read from, and is exported as
+// temporal_exporter_version_extraction_source; both are empty if no version
+// was found. Implementations must accept nil responses.
+type VersionExtractor interface {
+	ExtractFromSystemInfo(resp *v1.GetSystemInfoResponse) (version, source string)
+	ExtractFromClusterInfo(resp *v1.GetClusterInfoResponse) (version, source string)
+}
+
+// DefaultVersionExtractor scans the text form of the responses for a
+// version-like value after a known key, such as server_version.
+type DefaultVersionExtractor struct{}
+
+func (DefaultVersionExtractor) ExtractFromSystemInfo(resp *v1.GetSystemInfoResponse) (version, source string) {
+	if resp == nil {
+		return "", ""
+	}
+	return extractVersionFromSystemInfo(resp.String())
+}
+
+func (DefaultVersionExtractor) ExtractFromClusterInfo(resp *v1.GetClusterInfoResponse) (version, source string) {
+	if resp == nil {
+		return "", ""
+	}
+	return extractVersionFromClusterInfo(resp.String())
+}
+
+// setExtractionSource exports source as the way addr's version was found,
+// or deletes the series if source is empty. It must be called with
+// s.metricsMu held.
+func (s *Scraper) setExtractionSource(addr, source string) {
+	s.extractionSourceGauge.DeletePartialMatch(prometheus.Labels{"address": addr})
+	if source != "" {
+		s.extractionSourceGauge.WithLabelValues(addr, source).Set(1)
+	}
+}
@@ -0,0 +1,155 @@
+//go:build ignore
+
+// gen_release_dates writes release_dates.go, the table of Temporal server
+// release dates used for temporal_server_version_age_seconds.
+//
+//	go run gen_release_dates.go                  # GitHub releases API
+//	go run gen_release_dates.go -source=goproxy  # Go module proxy tag times
+//
+// Set GITHUB_TOKEN to avoid the API's anonymous rate limit.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	source = flag.String("source", "github", "where to read release dates from: github or goproxy")
+	out    = flag.String("o", "release_dates.go", "output file")
+)
+
+// releaseRE matches final releases only; pre-releases and fork builds are
+// left out of the table.
+var releaseRE = regexp.MustCompile(`^v(\d+)\.(\d+)\.(\d+)$`)
+
+func main() {
+	flag.Parse()
+	var dates map[string]time.Time
+	var err error
+	switch *source {
+	case "github":
+		dates, err = fromGitHub()
+	case "goproxy":
+		dates, err = fromGoProxy()
+	default:
+		err = fmt.Errorf("unknown -source %q", *source)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	versions := make([]string, 0, len(dates))
+	for v := range dates {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return less(versions[i], versions[j]) })
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by gen_release_dates.go -source=%s; DO NOT EDIT.\n\n", *source)
+	b.WriteString("package scraper\n\n")
+	b.WriteString("// releaseDates maps Temporal server releases to the date they were tagged.\n")
+	b.WriteString("var releaseDates = map[string]string{\n")
+	for _, v := range versions {
+		fmt.Fprintf(&b, "\t%q: %q,\n", strings.TrimPrefix(v, "v"), dates[v].UTC().Format(time.DateOnly))
+	}
+	b.WriteString("}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func less(a, b string) bool {
+	ma, mb := releaseRE.FindStringSubmatch(a), releaseRE.FindStringSubmatch(b)
+	for i := 1; i <= 3; i++ {
+		x, _ := strconv.Atoi(ma[i])
+		y, _ := strconv.Atoi(mb[i])
+		if x != y {
+			return x < y
+		}
+	}
+	return false
+}
+
+func getJSON(url string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if tok := os.Getenv("GITHUB_TOKEN"); tok != "" && strings.HasPrefix(url, "https://api.github.com/") {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func fromGitHub() (map[string]time.Time, error) {
+	dates := map[string]time.Time{}
+	for page := 1; ; page++ {
+		var releases []struct {
+			TagName     string    `json:"tag_name"`
+			Draft       bool      `json:"draft"`
+			Prerelease  bool      `json:"prerelease"`
+			PublishedAt time.Time `json:"published_at"`
+		}
+		url := fmt.Sprintf("https://api.github.com/repos/temporalio/temporal/releases?per_page=100&page=%d", page)
+		if err := getJSON(url, &releases); err != nil {
+			return nil, err
+		}
+		if len(releases) == 0 {
+			return dates, nil
+		}
+		for _, r := range releases {
+			if !r.Draft && !r.Prerelease && releaseRE.MatchString(r.TagName) {
+				dates[r.TagName] = r.PublishedAt
+			}
+		}
+	}
+}
+
+func fromGoProxy() (map[string]time.Time, error) {
+	const base = "https://proxy.golang.org/go.temporal.io/server/@v/"
+	req, err := http.Get(base + "list")
+	if err != nil {
+		return nil, err
+	}
+	defer req.Body.Close()
+	var list bytes.Buffer
+	if _, err := list.ReadFrom(req.Body); err != nil {
+		return nil, err
+	}
+	dates := map[string]time.Time{}
+	for _, v := range strings.Fields(list.String()) {
+		if !releaseRE.MatchString(v) {
+			continue
+		}
+		var info struct{ Time time.Time }
+		if err := getJSON(base+v+".info", &info); err != nil {
+			return nil, err
+		}
+		dates[v] = info.Time
+	}
+	return dates, nil
+}
@@ -0,0 +1,80 @@
+package scraper
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthService is the service name the frontend reports its health under.
+const healthService = "temporal.api.workflowservice.v1.WorkflowService"
+
+// healthMetrics are the series of the gRPC health probe.
+type healthMetrics struct {
+	healthyGauge    *prometheus.GaugeVec
+	notServingTotal *prometheus.CounterVec
+}
+
+func newHealthMetrics() healthMetrics {
+	return healthMetrics{
+		healthyGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "frontend_healthy",
+				Help: "Whether the frontend's gRPC health service reports the WorkflowService as SERVING, as 1 or 0. Absent if the server does not implement the health service",
+			},
+			[]string{"address"},
+		),
+		notServingTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "frontend_not_serving_total",
+				Help: "Number of health checks the frontend answered with a status other than SERVING",
+			},
+			[]string{"address"},
+		),
+	}
+}
+
+// healthResult is the outcome of one health probe.
+type healthResult int
+
+const (
+	healthUnknown healthResult = iota // not probed, or not implemented
+	healthServing
+	healthNotServing
+	healthFailed // the call itself failed
+)
+
+// probeHealth calls the gRPC health service on conn.
+func probeHealth(ctx context.Context, addr string, conn *grpc.ClientConn) healthResult {
+	client := healthpb.NewHealthClient(conn)
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: healthService})
+	switch {
+	case status.Code(err) == codes.Unimplemented, status.Code(err) == codes.NotFound:
+		return healthUnknown
+	case err != nil:
+		return healthFailed
+	case resp.GetStatus() == healthpb.HealthCheckResponse_SERVING:
+		return healthServing
+	}
+	return healthNotServing
+}
+
+// setHealth exports the result of a health probe. It must be called with
+// s.metricsMu held.
+func (s *Scraper) setHealth(addr string, h healthResult) {
+	switch h {
+	case healthUnknown:
+		s.healthyGauge.DeleteLabelValues(addr)
+	case healthServing:
+		s.healthyGauge.WithLabelValues(addr).Set(1)
+	case healthNotServing:
+		s.notServingTotal.WithLabelValues(addr).Inc()
+		s.healthyGauge.WithLabelValues(addr).Set(0)
+	case healthFailed:
+		s.healthyGauge.WithLabelValues(addr).Set(0)
+	}
+}
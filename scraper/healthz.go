@@ -0,0 +1,20 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// healthzHandler is a liveness check: it answers 200 while refreshes keep
+// completing and 500 once they have stalled, without contacting Temporal.
+func (s *Scraper) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if s.Stalled() {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "no refresh completed since %s\n",
+			time.Unix(0, s.lastRefresh.Load()).UTC().Format(time.RFC3339))
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,100 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// historyEntry is a period during which a target ran one version.
+type historyEntry struct {
+	Version   string    `json:"version"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+func (e historyEntry) MarshalJSON() ([]byte, error) {
+	type entry historyEntry
+	return json.Marshal(struct {
+		entry
+		Duration string `json:"duration"`
+	}{entry(e), e.LastSeen.Sub(e.FirstSeen).String()})
+}
+
+// versionHistory is a ring buffer of the latest --version-history-size
+// entries, oldest first from start.
+type versionHistory struct {
+	entries []historyEntry
+	start   int
+}
+
+// observe records that version was detected at now: the current entry is
+// extended if the version is unchanged, otherwise a new one is opened,
+// replacing the oldest once there are size entries.
+func (h *versionHistory) observe(version string, now time.Time, size int) {
+	if n := len(h.entries); n > 0 {
+		cur := &h.entries[(h.start+n-1)%n]
+		if cur.Version == version {
+			cur.LastSeen = now
+			return
+		}
+	}
+	e := historyEntry{Version: version, FirstSeen: now, LastSeen: now}
+	if len(h.entries) < size {
+		// Not yet full, so start is still 0.
+		h.entries = append(h.entries, e)
+		return
+	}
+	h.entries[h.start] = e
+	h.start = (h.start + 1) % len(h.entries)
+}
+
+// newestFirst returns a copy of the entries sorted by first_seen
+// descending.
+func (h *versionHistory) newestFirst() []historyEntry {
+	n := len(h.entries)
+	out := make([]historyEntry, n)
+	for i := range out {
+		out[i] = h.entries[(h.start+n-1-i)%n]
+	}
+	return out
+}
+
+func validateVersionHistorySize(c Config) error {
+	if c.VersionHistorySize < 1 {
+		return fmt.Errorf("--version-history-size must be at least 1, got %d", c.VersionHistorySize)
+	}
+	return nil
+}
+
+// versionHistoryHandler serves the version history of the target given by
+// the address query parameter, or of every target keyed by address.
+func (s *Scraper) versionHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	var body any
+	s.metricsMu.RLock()
+	if addr := r.URL.Query().Get("address"); addr != "" {
+		st, ok := s.targetStates[addr]
+		if !ok {
+			s.metricsMu.RUnlock()
+			http.Error(w, "unknown address "+addr, http.StatusNotFound)
+			return
+		}
+		body = st.history.newestFirst()
+	} else {
+		all := make(map[string][]historyEntry, len(s.targetStates))
+		for addr, st := range s.targetStates {
+			all[addr] = st.history.newestFirst()
+		}
+		body = all
+	}
+	s.metricsMu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(body); err != nil {
+		slog.Error("writing /version-history response failed", "err", err)
+	}
+}
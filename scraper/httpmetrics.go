@@ -0,0 +1,52 @@
+package scraper
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// httpMetrics are the series of the exporter's HTTP server.
+type httpMetrics struct {
+	httpRequests     *prometheus.CounterVec
+	httpDuration     *prometheus.HistogramVec
+	httpResponseSize *prometheus.HistogramVec
+}
+
+func newHTTPMetrics() httpMetrics {
+	return httpMetrics{
+		httpRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "exporter_http_requests_total",
+				Help: "Number of HTTP requests served by the exporter, by status code, method and endpoint",
+			},
+			[]string{"code", "method", "handler"},
+		),
+		httpDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "exporter_http_request_duration_seconds",
+				Help:    "Time taken to serve HTTP requests, by status code, method and endpoint",
+				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			},
+			[]string{"code", "method", "handler"},
+		),
+		httpResponseSize: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "exporter_http_response_size_bytes",
+				Help:    "Size of HTTP response bodies, by status code, method and endpoint",
+				Buckets: prometheus.ExponentialBuckets(256, 4, 8),
+			},
+			[]string{"code", "method", "handler"},
+		),
+	}
+}
+
+// instrumentHandler counts the requests to next and observes their
+// duration and response size with the handler label set to name.
+func (s *Scraper) instrumentHandler(name string, next http.Handler) http.Handler {
+	labels := prometheus.Labels{"handler": name}
+	return promhttp.InstrumentHandlerCounter(s.httpRequests.MustCurryWith(labels),
+		promhttp.InstrumentHandlerDuration(s.httpDuration.MustCurryWith(labels),
+			promhttp.InstrumentHandlerResponseSize(s.httpResponseSize.MustCurryWith(labels), next)))
+}
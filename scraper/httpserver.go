@@ -0,0 +1,275 @@
+package scraper
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerConfig is the configuration of a Server. Zero durations and sizes
+// disable their limit.
+type ServerConfig struct {
+	// MetricsPath is the path of the metrics endpoint, /metrics if empty.
+	MetricsPath string
+
+	// WebConfigFile is an exporter-toolkit web configuration file
+	// enabling TLS, mTLS client verification and basic auth. It replaces
+	// TLSCertFile, TLSKeyFile, TLSMinVersion and BasicAuthUsersFile.
+	WebConfigFile string
+	// TLSCertFile and TLSKeyFile, if set, serve HTTPS with this key pair,
+	// reloaded when the files change. TLSMinVersion is the lowest version
+	// accepted: TLS10, TLS11, TLS12 or TLS13, TLS12 if empty.
+	TLSCertFile, TLSKeyFile string
+	TLSMinVersion           string
+	// BasicAuthUsersFile, if set, requires basic auth on every endpoint
+	// but /healthz, checked against its username:bcrypt-hash lines.
+	BasicAuthUsersFile string
+	// MetricsUsername and MetricsPassword, if set, require basic auth
+	// with these credentials on the metrics endpoint.
+	MetricsUsername, MetricsPassword string
+
+	// AllowedCIDRs, if set, are the only clients answered; others get
+	// 403. TrustProxy takes the client address from the last
+	// X-Forwarded-For entry, and AllowlistHealthz applies the list to
+	// /healthz, which is exempt otherwise.
+	AllowedCIDRs     []string
+	TrustProxy       bool
+	AllowlistHealthz bool
+
+	// AccessLog logs every request, other than those to /healthz and
+	// /readyz with AccessLogExcludeProbes.
+	AccessLog, AccessLogExcludeProbes bool
+
+	ReadTimeout, ReadHeaderTimeout, WriteTimeout, IdleTimeout time.Duration
+	MaxHeaderBytes                                            int
+	// ShutdownGracePeriod is how long requests in progress get to
+	// return once the context given to Serve, and with it theirs, is
+	// cancelled.
+	ShutdownGracePeriod time.Duration
+
+	// MaxRequests is the number of concurrent metrics requests, more are
+	// answered with 503; MetricsTimeout bounds gathering them; RateLimit
+	// is the metrics requests per second, with bursts of 3, beyond which
+	// requests are answered with 429.
+	MaxRequests    int
+	MetricsTimeout time.Duration
+	RateLimit      float64
+
+	// Pprof serves the net/http/pprof profiles, on PprofListenAddr if it
+	// is set and alongside the metrics otherwise.
+	Pprof           bool
+	PprofListenAddr string
+
+	// ReadyRequires is any or all: the targets that must have completed a
+	// successful refresh before /readyz reports ready, any if empty.
+	// ReadyStrict counts only targets whose latest refresh succeeded.
+	ReadyRequires string
+	ReadyStrict   bool
+
+	// Settings are shown by /config, keyed by name.
+	Settings map[string]Setting
+}
+
+// Server serves the HTTP endpoints of a Scraper: its metrics, the target
+// state and the health checks.
+type Server struct {
+	s   *Scraper
+	cfg ServerConfig
+
+	allowedPrefixes []netip.Prefix
+	tlsConfig       *tls.Config
+	handler         http.Handler
+
+	authMu    sync.RWMutex
+	authUsers map[string][]byte
+}
+
+// fixedPaths are the paths of the endpoints other than metrics.
+var fixedPaths = []string{"/", "/config", "/debug/status", "/healthz", "/readyz", "/targets", "/version", "/version-history"}
+
+// NewServer validates cfg and returns the Server of the endpoints of s.
+// Nothing is served until Serve is called.
+func NewServer(s *Scraper, cfg ServerConfig) (*Server, error) {
+	if cfg.MetricsPath == "" {
+		cfg.MetricsPath = "/metrics"
+	}
+	if cfg.ReadyRequires == "" {
+		cfg.ReadyRequires = "any"
+	}
+	srv := &Server{s: s, cfg: cfg}
+	for _, validate := range []func() error{
+		srv.validateMetricsPath, srv.validateMetricsAuth, srv.validatePprof, srv.validateMetricsHandler,
+		srv.validateMetricsRateLimit, srv.validateHTTPServer, srv.parseAllowedCIDRs, srv.validateReadiness,
+		srv.validateWebConfig,
+	} {
+		if err := validate(); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if srv.tlsConfig, err = srv.webTLSConfig(); err != nil {
+		return nil, err
+	}
+	if srv.tlsConfig != nil || srv.webConfigTLS() {
+		s.serverTLSEnabled.Set(1)
+	}
+
+	handler, err := srv.basicAuthHandler(srv.newMux())
+	if err != nil {
+		return nil, fmt.Errorf("invalid basic auth settings: %w", err)
+	}
+	srv.handler = srv.accessLogHandler(srv.allowlistHandler(handler))
+	return srv, nil
+}
+
+func (srv *Server) validateMetricsPath() error {
+	path := srv.cfg.MetricsPath
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("--metrics-path must start with /, got %q", path)
+	}
+	if slices.Contains(fixedPaths, path) {
+		return fmt.Errorf("--metrics-path %s is used by another endpoint", path)
+	}
+	if srv.pprofOnMux() && strings.HasPrefix(path, "/debug/pprof/") {
+		return fmt.Errorf("--metrics-path %s is used by --enable-pprof", path)
+	}
+	return nil
+}
+
+func (srv *Server) validateHTTPServer() error {
+	c := srv.cfg
+	if c.ReadTimeout < 0 || c.ReadHeaderTimeout < 0 || c.WriteTimeout < 0 || c.IdleTimeout < 0 {
+		return errors.New("--web-read-timeout, --web-read-header-timeout, --web-write-timeout and --web-idle-timeout must not be negative")
+	}
+	// The server stops reading a request once --web-read-timeout has passed,
+	// so a longer header timeout would never take effect.
+	if c.ReadTimeout != 0 && c.ReadHeaderTimeout > c.ReadTimeout {
+		return fmt.Errorf("--web-read-header-timeout (%s) must not be longer than --web-read-timeout (%s)", c.ReadHeaderTimeout, c.ReadTimeout)
+	}
+	if c.ShutdownGracePeriod < 0 {
+		return errors.New("--shutdown-grace-period must not be negative")
+	}
+	if c.MaxHeaderBytes < 0 {
+		return errors.New("--web-max-header-bytes must be positive")
+	}
+	return nil
+}
+
+// newMux returns the ServeMux with every HTTP endpoint, each instrumented
+// with its path as the handler label. The exporter never serves
+// http.DefaultServeMux, so handlers that libraries register there, such as
+// those of expvar or net/http/pprof, are not exposed.
+func (srv *Server) newMux() *http.ServeMux {
+	s := srv.s
+	mux := http.NewServeMux()
+	handle := func(pattern string, h http.Handler) {
+		mux.Handle(pattern, s.instrumentHandler(strings.TrimSuffix(pattern, "{$}"), h))
+	}
+	handle(srv.cfg.MetricsPath, srv.rateLimitHandler(srv.metricsAuthHandler(srv.metricsHandler())))
+	handle("/targets", http.HandlerFunc(s.targetsHandler))
+	handle("/version-history", http.HandlerFunc(s.versionHistoryHandler))
+	handle("/healthz", http.HandlerFunc(s.healthzHandler))
+	handle("/readyz", http.HandlerFunc(srv.readyzHandler))
+	handle("/version", http.HandlerFunc(s.versionHandler))
+	handle("/config", http.HandlerFunc(srv.configHandler))
+	handle("/debug/status", http.HandlerFunc(s.statusHandler))
+	handle("/{$}", http.HandlerFunc(srv.landingHandler))
+	if srv.pprofOnMux() {
+		registerPprof(mux)
+	}
+	return mux
+}
+
+// Handler returns the handler of every endpoint, with the access log,
+// allowlist and basic auth applied but not TLS.
+func (srv *Server) Handler() http.Handler { return srv.handler }
+
+// newHTTPServer returns the server for the HTTP endpoints. Its handlers see
+// ctx as the parent of their request contexts, so they observe shutdown
+// once ctx is cancelled.
+func (srv *Server) newHTTPServer(ctx context.Context) *http.Server {
+	return &http.Server{
+		Handler:           srv.handler,
+		TLSConfig:         srv.tlsConfig,
+		ReadTimeout:       srv.cfg.ReadTimeout,
+		ReadHeaderTimeout: srv.cfg.ReadHeaderTimeout,
+		WriteTimeout:      srv.cfg.WriteTimeout,
+		IdleTimeout:       srv.cfg.IdleTimeout,
+		MaxHeaderBytes:    srv.cfg.MaxHeaderBytes,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+}
+
+// Serve serves the HTTP endpoints on l, and the profiles on
+// PprofListenAddr if it is set, until ctx is cancelled or serving fails.
+// The contexts of requests in progress are then cancelled, and they get
+// ShutdownGracePeriod to return. Serve returns nil after a shutdown and the
+// error otherwise.
+func (srv *Server) Serve(ctx context.Context, l net.Listener) error {
+	// httpCtx is also cancelled when serving fails, so that the handlers
+	// stop whenever the grace period begins.
+	httpCtx, cancelHTTP := context.WithCancel(ctx)
+	defer cancelHTTP()
+	hs := srv.newHTTPServer(httpCtx)
+	errc := make(chan error, 2)
+
+	var pprofSrv *http.Server
+	if srv.cfg.Pprof && !srv.pprofOnMux() {
+		pl, err := net.Listen("tcp", srv.cfg.PprofListenAddr)
+		if err != nil {
+			return fmt.Errorf("pprof http server failed: %w", err)
+		}
+		pprofSrv = srv.newPprofServer()
+		slog.Info("starting pprof server", "listen_addr", pl.Addr().String())
+		go func() { errc <- pprofSrv.Serve(pl) }()
+	}
+	go func() {
+		slog.Info("starting metrics server", "listen_addr", l.Addr().String(),
+			"tls", srv.tlsConfig != nil, "web_config_file", srv.cfg.WebConfigFile)
+		switch {
+		case srv.cfg.WebConfigFile != "":
+			errc <- srv.serveWebConfig(hs, l)
+		case srv.tlsConfig != nil:
+			errc <- hs.ServeTLS(l, "", "")
+		default:
+			errc <- hs.Serve(l)
+		}
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errc:
+	}
+	cancelHTTP()
+	srv.shutdownHTTPServer(hs)
+	if pprofSrv != nil {
+		pprofSrv.Close()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("metrics http server failed: %w", err)
+	}
+	return nil
+}
+
+// shutdownHTTPServer stops hs from accepting requests and waits up to
+// --shutdown-grace-period for those in progress to return, which they do
+// early once their contexts are cancelled. It then closes the connections
+// that remain.
+func (srv *Server) shutdownHTTPServer(hs *http.Server) {
+	ctx, stop := context.WithTimeout(context.Background(), srv.cfg.ShutdownGracePeriod)
+	defer stop()
+	if err := hs.Shutdown(ctx); err != nil {
+		slog.Warn("HTTP requests still in progress after the grace period, closing them", "err", err)
+		hs.Close()
+	}
+}
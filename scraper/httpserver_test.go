@@ -0,0 +1,105 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestStalledHTTPRequestIsCutOff(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	s, err := New(WithAddress(testAddr))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Stop()
+	const headerTimeout = 100 * time.Millisecond
+	srv, err := NewServer(s, ServerConfig{
+		ReadHeaderTimeout:   headerTimeout,
+		ReadTimeout:         time.Minute,
+		ShutdownGracePeriod: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- srv.Serve(ctx, l) }()
+	defer func() {
+		cancel()
+		if err := <-served; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	}()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// A client that never finishes its headers.
+	if _, err := io.WriteString(conn, "GET /metrics HTTP/1.1\r\nHost: localhost\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	conn.SetReadDeadline(start.Add(10 * headerTimeout))
+	if _, err := io.ReadAll(conn); err != nil {
+		t.Fatalf("connection still open after %s with a header timeout of %s: %v", time.Since(start), headerTimeout, err)
+	}
+}
+
+func TestShutdownCancelsRequestContexts(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	s, err := New(WithAddress(testAddr))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Stop()
+	const grace = time.Minute
+	srv, err := NewServer(s, ServerConfig{ShutdownGracePeriod: grace})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	// A handler that only returns once its request is cancelled.
+	started := make(chan struct{})
+	srv.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- srv.Serve(ctx, l) }()
+
+	requested := make(chan struct{})
+	go func() {
+		defer close(requested)
+		if resp, err := http.Get("http://" + l.Addr().String() + "/"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	case <-time.After(grace / 2):
+		t.Fatal("Serve waited for the grace period instead of cancelling the request")
+	}
+	<-requested
+	http.DefaultClient.CloseIdleConnections()
+}
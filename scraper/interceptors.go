@@ -0,0 +1,71 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// interceptorFactories builds the interceptors --grpc-interceptors can
+// name for a target.
+var interceptorFactories = map[string]func(s *Scraper, addr string) grpc.UnaryClientInterceptor{
+	"retry":   (*Scraper).retryInterceptor,
+	"logging": (*Scraper).loggingInterceptor,
+	"metrics": (*Scraper).metricsInterceptor,
+}
+
+func validateInterceptors(c Config) error {
+	for i, name := range c.Interceptors {
+		if _, ok := interceptorFactories[name]; !ok {
+			return fmt.Errorf("unknown --grpc-interceptors entry %q, want retry, logging or metrics", name)
+		}
+		if slices.Contains(c.Interceptors[:i], name) {
+			return fmt.Errorf("--grpc-interceptors names %s twice", name)
+		}
+	}
+	return nil
+}
+
+// interceptorChain returns the interceptors of the connection to addr: the
+// ones named by --grpc-interceptors in order, then the per-attempt
+// --grpc-request-timeout, which always applies.
+func (s *Scraper) interceptorChain(addr string) []grpc.UnaryClientInterceptor {
+	var chain []grpc.UnaryClientInterceptor
+	for _, name := range s.cfg.Interceptors {
+		chain = append(chain, interceptorFactories[name](s, addr))
+	}
+	return append(chain, s.timeoutInterceptor)
+}
+
+// timeoutInterceptor gives every attempt of an RPC --grpc-request-timeout.
+func (s *Scraper) timeoutInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.RequestTimeout)
+	defer cancel()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// loggingInterceptor logs every RPC to addr with its outcome, duration and
+// the request and response metadata.
+func (s *Scraper) loggingInterceptor(addr string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var header metadata.MD
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+		sent, _ := metadata.FromOutgoingContext(ctx)
+		slog.Info("rpc", "address", addr, "method", shortMethod(method), "grpc_code", status.Code(err).String(),
+			"duration", time.Since(start), "request_metadata", sent, "response_metadata", header)
+		return err
+	}
+}
+
+// shortMethod turns "/pkg.Service/GetSystemInfo" into "GetSystemInfo".
+func shortMethod(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
@@ -0,0 +1,58 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "go.temporal.io/api/workflowservice/v1"
+	"go.uber.org/goleak"
+)
+
+// stall answers an RPC only once its context is done.
+func stall[T any](ctx context.Context) (T, error) {
+	<-ctx.Done()
+	var zero T
+	return zero, ctx.Err()
+}
+
+func TestStalledRPCIsCutOff(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	const requestTimeout = 100 * time.Millisecond
+	tests := []struct {
+		name        string
+		clusterInfo func(context.Context) (*v1.GetClusterInfoResponse, error)
+		wantVersion string
+	}{
+		{
+			name: "GetSystemInfo stalls",
+			clusterInfo: func(context.Context) (*v1.GetClusterInfoResponse, error) {
+				return &v1.GetClusterInfoResponse{ServerVersion: "1.23.0"}, nil
+			},
+			wantVersion: "1.23.0",
+		},
+		{
+			name:        "both RPCs stall",
+			clusterInfo: stall[*v1.GetClusterInfoResponse],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeFrontend{systemInfo: stall[*v1.GetSystemInfoResponse], clusterInfo: tt.clusterInfo}
+			s, stop := newTestScraper(t, f, WithTimeouts(time.Second, requestTimeout))
+			defer stop()
+
+			// Each RPC gets requestTimeout; allow for slow machines, but
+			// not for RPCs that wait for the refresh's own context.
+			ctx, cancel := context.WithTimeout(context.Background(), 10*requestTimeout)
+			defer cancel()
+			results, err := s.RefreshOnce(ctx)
+			if err != nil {
+				t.Fatalf("RefreshOnce with a request timeout of %s: %v", requestTimeout, err)
+			}
+			if res := results[0]; res.Version != tt.wantVersion {
+				t.Errorf("version = %q, want %q", res.Version, tt.wantVersion)
+			}
+		})
+	}
+}
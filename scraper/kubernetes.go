@@ -0,0 +1,149 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// k8sPortNames are the Service port names taken to be the frontend port.
+var k8sPortNames = []string{"grpc", "temporal"}
+
+// kubeRESTConfig loads the client configuration from the kubeconfig file,
+// or the in-cluster service account if it is empty.
+func kubeRESTConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	return rest.InClusterConfig()
+}
+
+// runKubernetesDiscovery watches Services matching selector and passes
+// their frontend addresses to setTargets whenever they change, until ctx is
+// cancelled. It returns once the informer is running.
+func runKubernetesDiscovery(ctx context.Context, selector, kubeconfig string, setTargets func([]string)) error {
+	if _, err := labels.Parse(selector); err != nil {
+		return fmt.Errorf("invalid --kubernetes-service-selector: %w", err)
+	}
+	cfg, err := kubeRESTConfig(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("loading Kubernetes config: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("creating Kubernetes client: %w", err)
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0,
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) { o.LabelSelector = selector }))
+	services := factory.Core().V1().Services()
+	lister := services.Lister()
+
+	resync := func() {
+		svcs, err := lister.List(labels.Everything())
+		if err != nil {
+			slog.Error("listing Kubernetes services failed", "err", err)
+			return
+		}
+		var addrs []string
+		for _, svc := range svcs {
+			if addr, ok := serviceAddress(svc); ok {
+				addrs = append(addrs, addr)
+			}
+		}
+		sort.Strings(addrs)
+		setTargets(addrs)
+	}
+	if _, err := services.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(any) { resync() },
+		UpdateFunc: func(any, any) { resync() },
+		DeleteFunc: func(any) { resync() },
+	}); err != nil {
+		return err
+	}
+
+	factory.Start(ctx.Done())
+	for typ, ok := range factory.WaitForCacheSync(ctx.Done()) {
+		if !ok {
+			return fmt.Errorf("Kubernetes informer cache for %v did not sync", typ)
+		}
+	}
+	slog.Info("Kubernetes service discovery started", "selector", selector)
+	return nil
+}
+
+// listKubernetesTargets returns the addresses of the Services matching
+// selector at this moment, for a single refresh without an informer.
+func listKubernetesTargets(ctx context.Context, selector, kubeconfig string) ([]string, error) {
+	if _, err := labels.Parse(selector); err != nil {
+		return nil, fmt.Errorf("invalid --kubernetes-service-selector: %w", err)
+	}
+	cfg, err := kubeRESTConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("loading Kubernetes config: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating Kubernetes client: %w", err)
+	}
+	svcs, err := client.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("listing Kubernetes services: %w", err)
+	}
+	var addrs []string
+	for i := range svcs.Items {
+		if addr, ok := serviceAddress(&svcs.Items[i]); ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	return addrs, nil
+}
+
+// serviceAddress returns the host:port of svc's frontend port: the
+// ClusterIP, or an external address for Services without one.
+func serviceAddress(svc *corev1.Service) (string, bool) {
+	var port int32
+	for _, p := range svc.Spec.Ports {
+		for _, name := range k8sPortNames {
+			if p.Name == name {
+				port = p.Port
+			}
+		}
+	}
+	if port == 0 {
+		return "", false
+	}
+	host := svc.Spec.ClusterIP
+	if host == "" || host == corev1.ClusterIPNone {
+		host = ""
+		if len(svc.Spec.ExternalIPs) > 0 {
+			host = svc.Spec.ExternalIPs[0]
+		}
+		for _, ing := range svc.Status.LoadBalancer.Ingress {
+			if host != "" {
+				break
+			}
+			host = ing.IP
+			if host == "" {
+				host = ing.Hostname
+			}
+		}
+	}
+	if host == "" {
+		return "", false
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(port))), true
+}
@@ -0,0 +1,54 @@
+package scraper
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	labelNameRE  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+)
+
+func validateMetricPrefix(prefix string) error {
+	if !metricNameRE.MatchString(prefix) {
+		return fmt.Errorf("invalid metric name prefix '%s': must match [a-zA-Z_:][a-zA-Z0-9_:]*", prefix)
+	}
+	return nil
+}
+
+// metricLabelNames lists the variable label names used by the exporter's
+// metrics. Constant labels must not reuse them.
+var metricLabelNames = []string{
+	"address", "version", "revision", "goversion", "cluster_name", "capability", "prerelease",
+	"state", "from_state", "to_state", "method", "source",
+	"cluster_id", "persistence_store", "visibility_store", "status",
+	"error_type", "client", "min_version", "type", "expected", "constraint", "reason", "issuer_cn",
+	"tls_enabled", "tls_ca_cert_fingerprint", "api_key_configured", "build",
+	"code", "handler",
+}
+
+func validateLabelName(name string) error {
+	if !labelNameRE.MatchString(name) {
+		return fmt.Errorf("invalid Prometheus label name '%s': must match [a-zA-Z_][a-zA-Z0-9_]*", name)
+	}
+	if strings.HasPrefix(name, "__") {
+		return fmt.Errorf("invalid Prometheus label name '%s': names starting with __ are reserved for Prometheus", name)
+	}
+	return nil
+}
+
+// ValidateExtraLabel checks that name can be given to WithExtraLabels: it
+// must be a valid Prometheus label name that none of the metrics sets per
+// target.
+func ValidateExtraLabel(name string) error {
+	if err := validateLabelName(name); err != nil {
+		return err
+	}
+	if slices.Contains(metricLabelNames, name) {
+		return fmt.Errorf("constant label %q collides with a label the exporter sets per target", name)
+	}
+	return nil
+}
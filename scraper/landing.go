@@ -0,0 +1,55 @@
+package scraper
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sort"
+)
+
+var landingTemplate = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Temporal Version Exporter</title></head>
+<body>
+<h1>Temporal Version Exporter</h1>
+<p>Version {{.Version}} ({{.Revision}})</p>
+<p>{{len .Targets}} target{{if ne (len .Targets) 1}}s{{end}}{{range $i, $t := .Targets}}{{if $i}},{{else}}:{{end}} {{$t.Address}} ({{if $t.Version}}{{$t.Version}}{{else}}unknown{{end}}){{end}}</p>
+<ul>
+{{range .Links}}<li><a href="{{.}}">{{.}}</a></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+type landingTarget struct {
+	Address, Version string
+}
+
+// landingHandler serves a page naming the exporter and its endpoints, and
+// the last known version of every target.
+func (srv *Server) landingHandler(w http.ResponseWriter, r *http.Request) {
+	s := srv.s
+	data := struct {
+		Version, Revision string
+		Targets           []landingTarget
+		Links             []string
+	}{
+		Version:  buildVersion,
+		Revision: buildRevision,
+		Links:    append([]string{srv.cfg.MetricsPath}, fixedPaths[1:]...),
+	}
+	if srv.pprofOnMux() {
+		data.Links = append(data.Links, "/debug/pprof/")
+	}
+	s.metricsMu.RLock()
+	for addr, st := range s.targetStates {
+		data.Targets = append(data.Targets, landingTarget{addr, st.version})
+	}
+	s.metricsMu.RUnlock()
+	sort.Slice(data.Targets, func(i, j int) bool { return data.Targets[i].Address < data.Targets[j].Address })
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := landingTemplate.Execute(w, data); err != nil {
+		slog.Error("writing landing page failed", "err", err)
+	}
+}
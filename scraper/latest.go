@@ -0,0 +1,145 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// latestMetrics are the series of the latest release check.
+type latestMetrics struct {
+	latestReleaseGauge  *prometheus.GaugeVec
+	versionsBehindGauge *prometheus.GaugeVec
+	latestCheckErrors   prometheus.Counter
+}
+
+func newLatestMetrics() latestMetrics {
+	return latestMetrics{
+		latestReleaseGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_latest_release_info",
+				Help: "Always 1; labeled with the latest Temporal release on GitHub. Absent until the first successful check",
+			},
+			[]string{"version"},
+		),
+		versionsBehindGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_versions_behind",
+				Help: "Minor releases between the detected version and the latest Temporal release, 0 when up to date. Absent if either is unknown or their major versions differ",
+			},
+			[]string{"address"},
+		),
+		latestCheckErrors: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "exporter_latest_release_check_errors_total",
+				Help: "Number of failed lookups of the latest Temporal release",
+			},
+		),
+	}
+}
+
+const (
+	latestReleaseURL     = "https://api.github.com/repos/temporalio/temporal/releases/latest"
+	latestReleaseTimeout = 10 * time.Second
+)
+
+// setVersionsBehind exports how far addr's version is behind the latest
+// release. It must be called with s.metricsMu held.
+func (s *Scraper) setVersionsBehind(addr, version string) {
+	cur, ok1 := parseSemver(version)
+	latest, ok2 := parseSemver(s.latestRelease)
+	if !ok1 || !ok2 || cur.major != latest.major {
+		s.versionsBehindGauge.DeleteLabelValues(addr)
+		return
+	}
+	behind := 0.0
+	if latest.minor > cur.minor {
+		behind = float64(latest.minor - cur.minor)
+	}
+	s.versionsBehindGauge.WithLabelValues(addr).Set(behind)
+}
+
+// runLatestReleaseCheck looks up the latest release every
+// --latest-version-check-interval until ctx is cancelled. A single loop
+// serves all targets, and failures only keep the previous result.
+func (s *Scraper) runLatestReleaseCheck(ctx context.Context) {
+	client := &http.Client{Timeout: latestReleaseTimeout}
+	var etag string
+	for {
+		tag, newETag, err := fetchLatestRelease(ctx, client, etag)
+		switch {
+		case err != nil:
+			s.latestCheckErrors.Inc()
+			slog.Warn("latest release check failed", "err", err)
+		case tag != "":
+			etag = newETag
+			s.setLatestRelease(strings.TrimPrefix(tag, "v"))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.cfg.LatestCheckInterval):
+		}
+	}
+}
+
+// fetchLatestRelease returns the tag of the latest release, or an empty
+// tag if it is unchanged since the response that carried etag.
+func fetchLatestRelease(ctx context.Context, client *http.Client, etag string) (tag, newETag string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, latestReleaseTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, latestReleaseURL, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", userAgent())
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return "", etag, nil
+	case http.StatusOK:
+	default:
+		return "", "", fmt.Errorf("GET %s: %s", latestReleaseURL, resp.Status)
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", "", fmt.Errorf("decoding latest release: %w", err)
+	}
+	if release.TagName == "" {
+		return "", "", fmt.Errorf("latest release has no tag")
+	}
+	return release.TagName, resp.Header.Get("ETag"), nil
+}
+
+// setLatestRelease records a newly fetched latest release and updates
+// every target's distance from it.
+func (s *Scraper) setLatestRelease(version string) {
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+	if version == s.latestRelease {
+		return
+	}
+	slog.Info("latest Temporal release", "version", version)
+	s.latestRelease = version
+	s.latestReleaseGauge.Reset()
+	s.latestReleaseGauge.WithLabelValues(version).Set(1)
+	for addr, st := range s.targetStates {
+		s.setVersionsBehind(addr, st.version)
+	}
+}
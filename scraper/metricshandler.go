@@ -0,0 +1,40 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func (srv *Server) validateMetricsHandler() error {
+	if srv.cfg.MaxRequests < 0 {
+		return errors.New("--web-max-requests must not be negative")
+	}
+	if srv.cfg.MetricsTimeout < 0 {
+		return errors.New("--web-metrics-timeout must not be negative")
+	}
+	return nil
+}
+
+// promhttpLogger passes promhttp's gather and encoding errors to slog.
+type promhttpLogger struct{}
+
+func (promhttpLogger) Println(v ...any) {
+	slog.Error("serving metrics failed", "err", fmt.Sprint(v...))
+}
+
+// metricsHandler serves the metrics of the Scraper's registry. Its
+// requests, including those rejected by --web-max-requests, are counted in
+// promhttp_metric_handler_requests_total on the registry.
+func (srv *Server) metricsHandler() http.Handler {
+	reg := srv.s.Registry()
+	return promhttp.InstrumentMetricHandler(reg, promhttp.HandlerFor(reg, promhttp.HandlerOpts{
+		ErrorLog:            promhttpLogger{},
+		Registry:            reg,
+		MaxRequestsInFlight: srv.cfg.MaxRequests,
+		Timeout:             srv.cfg.MetricsTimeout,
+	}))
+}
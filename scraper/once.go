@@ -0,0 +1,57 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// RefreshOnce discovers the targets once, refreshes each of them once,
+// concurrently, and returns the results in discovery order. The results
+// are also reported to the MetricBackend, as in the refresh loops. It must
+// not be combined with Start.
+func (s *Scraper) RefreshOnce(ctx context.Context) ([]ScrapeResult, error) {
+	var discovered []string
+	switch {
+	case s.cfg.KubernetesSelector != "":
+		addrs, err := listKubernetesTargets(ctx, s.cfg.KubernetesSelector, s.cfg.Kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("Kubernetes discovery failed: %w", err)
+		}
+		discovered = addrs
+	case isSRVName(s.cfg.Address):
+		lookupCtx, cancel := context.WithTimeout(ctx, s.cfg.DialTimeout)
+		addrs, err := lookupSRVTargets(lookupCtx, s.cfg.Address)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("SRV lookup of %s failed: %w", s.cfg.Address, err)
+		}
+		discovered = addrs
+	default:
+		discovered = []string{s.cfg.Address}
+	}
+
+	addrs := normalizeTargets(discovered)
+	results := make([]ScrapeResult, len(addrs))
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		cfg, err := resolveConnConfig(addr, s.cfg)
+		if err != nil {
+			results[i] = ScrapeResult{Address: addr, Err: err}
+			slog.Error("skipping target", "address", addr, "err", err)
+			continue
+		}
+		s.initTarget(addr)
+		wg.Go(func() {
+			defer s.closeConn(addr)
+			results[i] = s.refresh(ctx, addr, cfg)
+			s.record(ctx, results[i], cfg)
+		})
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
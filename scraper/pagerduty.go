@@ -0,0 +1,160 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pagerDutyMetrics are the series of the PagerDuty integration.
+type pagerDutyMetrics struct {
+	pagerDutyEvents *prometheus.CounterVec
+}
+
+func newPagerDutyMetrics() pagerDutyMetrics {
+	return pagerDutyMetrics{
+		pagerDutyEvents: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "exporter_pagerduty_events_total",
+				Help: "Number of PagerDuty events sent, by event type (trigger, resolve) and outcome after retries (success, failure, dropped)",
+			},
+			[]string{"type", "status"},
+		),
+	}
+}
+
+const (
+	pagerDutyURL     = "https://events.pagerduty.com/v2/enqueue"
+	pagerDutyTimeout = 10 * time.Second
+	pagerDutyRetries = 3
+)
+
+// pagerDutyEvent is an Events API v2 request body.
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary  string `json:"summary"`
+	Source   string `json:"source"`
+	Severity string `json:"severity"`
+}
+
+// pagerDutyFailure triggers an alert once addr has failed
+// --pagerduty-failure-threshold consecutive times. It must be called with
+// s.metricsMu held, after st.failures has been updated.
+func (s *Scraper) pagerDutyFailure(addr string, st *targetState) {
+	if s.cfg.PagerDutyRoutingKey == "" || s.cfg.Offline || st.paged || st.failures < s.cfg.PagerDutyThreshold {
+		return
+	}
+	st.paged = true
+	s.enqueuePagerDuty(pagerDutyEvent{
+		EventAction: "trigger",
+		DedupKey:    "temporal_version_unknown_" + addr,
+		Payload: &pagerDutyPayload{
+			Summary:  "Temporal version unknown at " + addr,
+			Source:   addr,
+			Severity: "warning",
+		},
+	})
+}
+
+// pagerDutySuccess resolves the alert of a target that has recovered. It
+// must be called with s.metricsMu held.
+func (s *Scraper) pagerDutySuccess(addr string, st *targetState) {
+	if !st.paged {
+		return
+	}
+	st.paged = false
+	s.enqueuePagerDuty(pagerDutyEvent{
+		EventAction: "resolve",
+		DedupKey:    "temporal_version_unknown_" + addr,
+	})
+}
+
+func (s *Scraper) enqueuePagerDuty(ev pagerDutyEvent) {
+	s.pagerDutyStartOnce.Do(func() { s.bg.Go(s.runPagerDuty) })
+	ev.RoutingKey = s.cfg.PagerDutyRoutingKey
+	select {
+	case s.pagerDutyQueue <- ev:
+	default:
+		s.pagerDutyEvents.WithLabelValues(ev.EventAction, "dropped").Inc()
+		slog.Error("PagerDuty queue full, dropping event", "action", ev.EventAction, "dedup_key", ev.DedupKey)
+	}
+}
+
+// runPagerDuty sends the queued events in order until Stop is called.
+// Events still queued then are dropped.
+func (s *Scraper) runPagerDuty() {
+	for {
+		var ev pagerDutyEvent
+		select {
+		case <-s.background.Done():
+			return
+		case ev = <-s.pagerDutyQueue:
+		}
+		if err := sendPagerDuty(s.background, ev); err != nil {
+			s.pagerDutyEvents.WithLabelValues(ev.EventAction, "failure").Inc()
+			slog.Error("PagerDuty event failed", "action", ev.EventAction, "dedup_key", ev.DedupKey, "err", err)
+			continue
+		}
+		s.pagerDutyEvents.WithLabelValues(ev.EventAction, "success").Inc()
+	}
+}
+
+// sendPagerDuty posts ev, retrying rate-limited and server errors until
+// ctx is cancelled.
+func sendPagerDuty(ctx context.Context, ev pagerDutyEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = postPagerDuty(ctx, body)
+		if err == nil || !retry || attempt >= pagerDutyRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// postPagerDuty makes one request and reports whether a failure may be
+// retried.
+func postPagerDuty(ctx context.Context, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, pagerDutyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pagerDutyURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusAccepted {
+		return false, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("PagerDuty returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+}
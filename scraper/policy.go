@@ -0,0 +1,153 @@
+package scraper
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// policyMetrics are the series of the version policies.
+type policyMetrics struct {
+	belowMinimumGauge   *prometheus.GaugeVec
+	minimumUncomparable *prometheus.CounterVec
+	constraintGauge     *prometheus.GaugeVec
+	mismatchGauge       *prometheus.GaugeVec
+}
+
+func newPolicyMetrics() policyMetrics {
+	return policyMetrics{
+		belowMinimumGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_below_minimum",
+				Help: "1 if the detected version is below --min-version, or cannot be compared with it because it is unknown or not semver; 0 otherwise",
+			},
+			[]string{"address", "min_version"},
+		),
+		minimumUncomparable: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "server_version_minimum_uncomparable_total",
+				Help: "Number of refreshes whose version could not be compared with --min-version because it was unknown or not semver",
+			},
+			[]string{"address"},
+		),
+		constraintGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_constraint_satisfied",
+				Help: "1 if the detected version satisfies its constraint, from the targets file or --version-constraint; 0 if it does not or is unknown or not semver",
+			},
+			[]string{"address", "constraint"},
+		),
+		mismatchGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_mismatch",
+				Help: "1 if the detected version differs from the expected one, from the targets file or --expected-version, ignoring build metadata, or is unknown or not semver; 0 otherwise",
+			},
+			[]string{"address", "expected"},
+		),
+	}
+}
+
+// parsePolicyVersions validates --min-version, --expected-version and
+// --version-constraint and stores them parsed.
+func (s *Scraper) parsePolicyVersions() error {
+	if s.cfg.VersionConstraint != "" {
+		c, err := parseVersionRange(s.cfg.VersionConstraint)
+		if err != nil {
+			return fmt.Errorf("invalid --version-constraint %q: %w", s.cfg.VersionConstraint, err)
+		}
+		s.versionConstraint = c
+	}
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *semVersion
+	}{
+		{"min-version", s.cfg.MinVersion, &s.minSemver},
+		{"expected-version", s.cfg.ExpectedVersion, &s.expectedSemver},
+	} {
+		if f.value == "" {
+			continue
+		}
+		sv, ok := parseSemver(f.value)
+		if !ok {
+			return fmt.Errorf("--%s must be a semver version such as 1.22.0, got %q", f.name, f.value)
+		}
+		*f.dst = sv
+	}
+	return nil
+}
+
+// checkMinVersion exports whether version is below --min-version; an empty
+// version means it is unknown. A pre-release of the minimum is below it. It
+// must be called with s.metricsMu held.
+func (s *Scraper) checkMinVersion(addr, version string) {
+	if s.cfg.MinVersion == "" {
+		return
+	}
+	below := 1.0
+	if sv, ok := parseSemver(version); ok {
+		if sv.compare(s.minSemver) >= 0 {
+			below = 0
+		}
+	} else {
+		s.minimumUncomparable.WithLabelValues(addr).Inc()
+	}
+	s.belowMinimumGauge.WithLabelValues(addr, s.cfg.MinVersion).Set(below)
+}
+
+// expectedVersion returns the version addr should run and its parsed form:
+// the expected_version of its entry in the targets file, or else
+// --expected-version. It must be called with s.metricsMu held.
+func (s *Scraper) expectedVersion(addr string) (string, semVersion) {
+	if p := s.targetPolicies[addr]; p.expected != "" {
+		return p.expected, p.expectedSemver
+	}
+	return s.cfg.ExpectedVersion, s.expectedSemver
+}
+
+// checkExpectedVersion exports whether version differs from the expected
+// version of addr; an empty version means it is unknown. Pre-release
+// identifiers must match, build metadata is ignored. It must be called with
+// s.metricsMu held.
+func (s *Scraper) checkExpectedVersion(addr, version string) {
+	expected, want := s.expectedVersion(addr)
+	// A reload of the targets file may have changed the expected version.
+	s.mismatchGauge.DeletePartialMatch(prometheus.Labels{"address": addr})
+	if expected == "" {
+		return
+	}
+	mismatch := 1.0
+	if sv, ok := parseSemver(version); ok && sv.compare(want) == 0 {
+		mismatch = 0
+	}
+	s.mismatchGauge.WithLabelValues(addr, expected).Set(mismatch)
+}
+
+// constraint returns the semver range addr should satisfy and its parsed
+// form: the version_constraint of its entry in the targets file, or else
+// --version-constraint. The range is nil if neither is set. It must be
+// called with s.metricsMu held.
+func (s *Scraper) constraint(addr string) (string, *versionRange) {
+	if p := s.targetPolicies[addr]; p.constraint != "" {
+		return p.constraint, p.versionRange
+	}
+	return s.cfg.VersionConstraint, s.versionConstraint
+}
+
+// checkConstraint exports whether version satisfies the constraint of addr;
+// an empty version means it is unknown, and one that is not a full semver
+// version never satisfies it. See versionRange for the pre-release rule. It
+// must be called with s.metricsMu held.
+func (s *Scraper) checkConstraint(addr, version string) {
+	constraint, r := s.constraint(addr)
+	// A reload of the targets file may have changed the constraint.
+	s.constraintGauge.DeletePartialMatch(prometheus.Labels{"address": addr})
+	if r == nil {
+		return
+	}
+	satisfied := 0.0
+	if sv, ok := parseSemver(version); ok && r.check(sv) {
+		satisfied = 1
+	}
+	s.constraintGauge.WithLabelValues(addr, constraint).Set(satisfied)
+}
@@ -0,0 +1,170 @@
+package scraper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "go.temporal.io/api/workflowservice/v1"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestVersionPolicies(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	const (
+		minVersion = "1.23.0"
+		expected   = "1.24.2"
+		constraint = ">=1.23.0 <1.25.0"
+	)
+	tests := []struct {
+		version string // empty for a failed refresh
+		// The expected values of the three policy series.
+		below, mismatch, satisfied float64
+	}{
+		{"1.24.2", 0, 0, 1},
+		{"1.24.2+deadbeef", 0, 0, 1},
+		{"1.23.0", 0, 1, 1},
+		{"1.22.9", 1, 1, 0},
+		{"1.25.0", 0, 1, 0},
+		{"1.23.0-rc.1", 1, 1, 0},
+		// Versions that are not full semver never satisfy a policy.
+		{"1.24", 1, 1, 0},
+		{"", 1, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			f := &fakeFrontend{}
+			if tt.version != "" {
+				f.setVersion(tt.version)
+			} else {
+				f.systemInfo = func(context.Context) (*v1.GetSystemInfoResponse, error) {
+					return nil, status.Error(codes.Internal, "boom")
+				}
+			}
+			s, stop := newTestScraper(t, f, WithVersionPolicy(minVersion, expected, constraint))
+			defer stop()
+			refreshOnce(t, s)
+
+			if v := testutil.ToFloat64(s.belowMinimumGauge.WithLabelValues(testAddr, minVersion)); v != tt.below {
+				t.Errorf("below minimum = %v, want %v", v, tt.below)
+			}
+			if v := testutil.ToFloat64(s.mismatchGauge.WithLabelValues(testAddr, expected)); v != tt.mismatch {
+				t.Errorf("mismatch = %v, want %v", v, tt.mismatch)
+			}
+			if v := testutil.ToFloat64(s.constraintGauge.WithLabelValues(testAddr, constraint)); v != tt.satisfied {
+				t.Errorf("constraint satisfied = %v, want %v", v, tt.satisfied)
+			}
+		})
+	}
+}
+
+func TestVersionPolicyValidation(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	for _, tt := range []struct {
+		minVersion, expected, constraint string
+	}{
+		{"1.23", "", ""},
+		{"", "latest", ""},
+		{"", "", ">=1.23.0 <"},
+		{"", "", "~>1.23"},
+	} {
+		if s, err := New(WithAddress(testAddr), WithVersionPolicy(tt.minVersion, tt.expected, tt.constraint)); err == nil {
+			s.Stop()
+			t.Errorf("New accepted the version policy %+v", tt)
+		}
+	}
+}
+
+// writeTargetsFile writes a targets file with the given contents to a
+// temporary directory and returns its path.
+func writeTargetsFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "targets.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTargetsFileExpectedVersion(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	path := writeTargetsFile(t, "targets:\n  - address: "+testAddr+"\n    expected_version: 1.23.0\n")
+	f := &fakeFrontend{}
+	f.setVersion("1.23.0")
+	s, stop := newTestScraper(t, f, WithVersionPolicy("", "1.24.2", ""), WithTargetsFile(path))
+	defer stop()
+
+	refreshOnce(t, s)
+	if v := testutil.ToFloat64(s.mismatchGauge.WithLabelValues(testAddr, "1.23.0")); v != 0 {
+		t.Errorf("mismatch with the target's expected version = %v, want 0", v)
+	}
+	if n := testutil.CollectAndCount(s.mismatchGauge); n != 1 {
+		t.Errorf("%d mismatch series, want only the target's", n)
+	}
+
+	// Without the entry the target falls back to --expected-version.
+	if err := os.WriteFile(path, []byte("targets: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReloadTargets(); err != nil {
+		t.Fatalf("ReloadTargets: %v", err)
+	}
+	refreshOnce(t, s)
+	if v := testutil.ToFloat64(s.mismatchGauge.WithLabelValues(testAddr, "1.24.2")); v != 1 {
+		t.Errorf("mismatch with --expected-version = %v, want 1", v)
+	}
+	if n := testutil.CollectAndCount(s.mismatchGauge); n != 1 {
+		t.Errorf("%d mismatch series after the reload, want 1", n)
+	}
+}
+
+func TestTargetsFileValidation(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	for _, contents := range []string{
+		"targets:\n  - address: " + testAddr + "\n    expected_version: latest\n",
+		"targets:\n  - expected_version: 1.23.0\n",
+		"targets:\n  - address: " + testAddr + "\n  - address: " + testAddr + "\n",
+		"targets:\n  - address: " + testAddr + "\n    expected: 1.23.0\n",
+		"targets:\n  - address: " + testAddr + "\n    version_constraint: ~>1.23\n",
+		"targets: [",
+	} {
+		if s, err := New(WithAddress(testAddr), WithTargetsFile(writeTargetsFile(t, contents))); err == nil {
+			s.Stop()
+			t.Errorf("New accepted the targets file %q", contents)
+		}
+	}
+}
+
+func TestTargetsFileConstraint(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	const constraint = "1.22.x"
+	path := writeTargetsFile(t, "targets:\n  - address: "+testAddr+"\n    version_constraint: "+constraint+"\n")
+	f := &fakeFrontend{}
+	f.setVersion("1.22.5")
+	s, stop := newTestScraper(t, f, WithVersionPolicy("", "", ">=1.23.0"), WithTargetsFile(path))
+	defer stop()
+
+	refreshOnce(t, s)
+	if v := testutil.ToFloat64(s.constraintGauge.WithLabelValues(testAddr, constraint)); v != 1 {
+		t.Errorf("constraint satisfied = %v, want 1", v)
+	}
+
+	// An invalid constraint fails the reload and keeps the previous one.
+	if err := os.WriteFile(path, []byte("targets:\n  - address: "+testAddr+"\n    version_constraint: \">=1.23.0 <\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReloadTargets(); err == nil {
+		t.Fatal("ReloadTargets accepted an invalid version_constraint")
+	}
+	refreshOnce(t, s)
+	if v := testutil.ToFloat64(s.constraintGauge.WithLabelValues(testAddr, constraint)); v != 1 {
+		t.Errorf("constraint satisfied after the failed reload = %v, want 1", v)
+	}
+	if n := testutil.CollectAndCount(s.constraintGauge); n != 1 {
+		t.Errorf("%d constraint series, want only the target's", n)
+	}
+}
@@ -0,0 +1,39 @@
+package scraper
+
+import (
+	"errors"
+	"net/http"
+	"net/http/pprof"
+)
+
+func (srv *Server) validatePprof() error {
+	if srv.cfg.PprofListenAddr != "" && !srv.cfg.Pprof {
+		return errors.New("--pprof-listen-addr requires --enable-pprof")
+	}
+	return nil
+}
+
+// pprofOnMux reports whether the profiles are served on the metrics port.
+func (srv *Server) pprofOnMux() bool {
+	return srv.cfg.Pprof && srv.cfg.PprofListenAddr == ""
+}
+
+// registerPprof adds the net/http/pprof handlers to mux. They are
+// registered explicitly because the package's own init only registers them
+// on http.DefaultServeMux, which the exporter does not serve.
+func registerPprof(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+// newPprofServer returns the server of the profiles on --pprof-listen-addr,
+// without the TLS, auth and write timeout of the metrics port, so that
+// long CPU profiles and traces work.
+func (srv *Server) newPprofServer() *http.Server {
+	mux := http.NewServeMux()
+	registerPprof(mux)
+	return &http.Server{Handler: mux, ReadHeaderTimeout: srv.cfg.ReadHeaderTimeout}
+}
@@ -0,0 +1,54 @@
+package scraper
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitMetrics are the series of the metrics rate limit.
+type rateLimitMetrics struct {
+	rateLimited prometheus.Counter
+}
+
+func newRateLimitMetrics() rateLimitMetrics {
+	return rateLimitMetrics{
+		rateLimited: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "exporter_http_rate_limited_total",
+			Help: "Number of metrics requests rejected with 429 by --metrics-rate-limit",
+		}),
+	}
+}
+
+func (srv *Server) validateMetricsRateLimit() error {
+	if l := srv.cfg.RateLimit; l < 0 || math.IsNaN(l) || math.IsInf(l, 0) {
+		return errors.New("--metrics-rate-limit must be a finite, non-negative number")
+	}
+	return nil
+}
+
+// rateLimitHandler wraps next so that requests beyond --metrics-rate-limit
+// are answered with 429 before any work is done. It returns next unchanged
+// if no limit is set.
+func (srv *Server) rateLimitHandler(next http.Handler) http.Handler {
+	if srv.cfg.RateLimit == 0 {
+		return next
+	}
+	limiter := rate.NewLimiter(rate.Limit(srv.cfg.RateLimit), 3)
+	// The time until the next token, rounded up to whole seconds.
+	retryAfter := strconv.Itoa(int(math.Ceil(1 / srv.cfg.RateLimit)))
+	s := srv.s
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !limiter.Allow() {
+			s.rateLimited.Inc()
+			w.Header().Set("Retry-After", retryAfter)
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,53 @@
+package scraper
+
+import (
+	"log/slog"
+	"time"
+)
+
+// refreshDone records the completion of a refresh; first is true for the
+// target's first refresh.
+func (s *Scraper) refreshDone(first bool) {
+	s.lastRefresh.Store(time.Now().UnixNano())
+	if first && s.pendingFirst.Add(-1) == 0 {
+		s.markReady()
+	}
+}
+
+// markReady closes the channel returned by Ready, once.
+func (s *Scraper) markReady() {
+	s.readyOnce.Do(func() {
+		slog.Info("first scrape cycle complete")
+		close(s.ready)
+	})
+}
+
+// Ready returns a channel that is closed once every target known at the
+// time has completed its first refresh, successful or not.
+func (s *Scraper) Ready() <-chan struct{} { return s.ready }
+
+// refreshBudget is how long the slowest possible refresh, plus the wait
+// before the next one, can take.
+func (s *Scraper) refreshBudget() time.Duration {
+	backoff := time.Duration(0)
+	for i, b := 0, retryBackoff; i < s.cfg.MaxRetries; i, b = i+1, b*2 {
+		backoff += b
+	}
+	// GetSystemInfo and GetClusterInfo each take up to maxRetries+1 attempts.
+	perRPC := time.Duration(s.cfg.MaxRetries+1)*(s.cfg.RequestTimeout) + backoff
+	return max(s.cfg.ScrapeInterval, s.maxInterval()) + s.cfg.DialTimeout + 2*perRPC
+}
+
+// LastRefresh returns when the latest refresh of any target completed, or
+// the Scraper was created if none has.
+func (s *Scraper) LastRefresh() time.Time { return time.Unix(0, s.lastRefresh.Load()) }
+
+// Stalled reports whether targets are configured but none has completed a
+// refresh within twice the longest a refresh and the wait before the next
+// one can take, as when the refresh loops have hung.
+func (s *Scraper) Stalled() bool {
+	s.runnersMu.Lock()
+	n := len(s.runners)
+	s.runnersMu.Unlock()
+	return n > 0 && time.Since(s.LastRefresh()) > 2*s.refreshBudget()
+}
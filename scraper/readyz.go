@@ -0,0 +1,74 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+)
+
+func (srv *Server) validateReadiness() error {
+	switch srv.cfg.ReadyRequires {
+	case "any", "all":
+		return nil
+	default:
+		return fmt.Errorf("--ready-requires must be any or all, got %q", srv.cfg.ReadyRequires)
+	}
+}
+
+// targetReadiness is the /readyz representation of a target.
+type targetReadiness struct {
+	Address   string `json:"address"`
+	Ready     bool   `json:"ready"`
+	Succeeded bool   `json:"succeeded"`
+	Failures  int    `json:"consecutive_failures"`
+}
+
+type readiness struct {
+	Ready    bool              `json:"ready"`
+	Requires string            `json:"requires"`
+	Strict   bool              `json:"strict"`
+	Targets  []targetReadiness `json:"targets"`
+}
+
+// readyzHandler answers 200 once the targets selected by --ready-requires
+// have completed a successful refresh and 503 until then, with the
+// readiness of every target as JSON.
+func (srv *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	s := srv.s
+	res := readiness{Requires: srv.cfg.ReadyRequires, Strict: srv.cfg.ReadyStrict, Targets: []targetReadiness{}}
+	s.metricsMu.RLock()
+	for addr, st := range s.targetStates {
+		res.Targets = append(res.Targets, targetReadiness{
+			Address:   addr,
+			Ready:     st.succeeded && (!srv.cfg.ReadyStrict || st.failures == 0),
+			Succeeded: st.succeeded,
+			Failures:  st.failures,
+		})
+	}
+	s.metricsMu.RUnlock()
+	sort.Slice(res.Targets, func(i, j int) bool { return res.Targets[i].Address < res.Targets[j].Address })
+
+	ready := 0
+	for _, t := range res.Targets {
+		if t.Ready {
+			ready++
+		}
+	}
+	if srv.cfg.ReadyRequires == "all" {
+		res.Ready = ready > 0 && ready == len(res.Targets)
+	} else {
+		res.Ready = ready > 0
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !res.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(res); err != nil {
+		slog.Error("writing /readyz response failed", "err", err)
+	}
+}
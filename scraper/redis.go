@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisKeyPrefix = "temporal:version:"
+	redisTimeout   = 2 * time.Second
+)
+
+// redisLogger sends go-redis's internal messages, which repeat every
+// failure the exporter already reports, to the debug log.
+type redisLogger struct{}
+
+func (redisLogger) Printf(_ context.Context, format string, v ...any) {
+	slog.Debug("redis: " + fmt.Sprintf(format, v...))
+}
+
+// openRedis connects to Redis if --redis-addr is set. An unreachable Redis
+// is only logged; writes are still attempted, in case it comes back.
+func (s *Scraper) openRedis(ctx context.Context) {
+	if s.cfg.RedisAddr == "" {
+		return
+	}
+	if s.cfg.Offline {
+		slog.Warn("--offline is set, not using Redis", "redis_addr", s.cfg.RedisAddr)
+		return
+	}
+	redis.SetLogger(redisLogger{})
+	s.redisClient = redis.NewClient(&redis.Options{
+		Addr:         s.cfg.RedisAddr,
+		Password:     s.cfg.RedisPassword,
+		DB:           s.cfg.RedisDB,
+		DialTimeout:  redisTimeout,
+		ReadTimeout:  redisTimeout,
+		WriteTimeout: redisTimeout,
+	})
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	if err := s.redisClient.Ping(ctx).Err(); err != nil {
+		slog.Warn("Redis is unavailable, continuing without cached versions", "redis_addr", s.cfg.RedisAddr, "err", err)
+		return
+	}
+	s.redisUp = true
+}
+
+// storeVersion caches addr's version for ttl.
+func (s *Scraper) storeVersion(ctx context.Context, addr, version string, ttl time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	if err := s.redisClient.Set(ctx, redisKeyPrefix+addr, version, ttl).Err(); err != nil {
+		slog.Warn("caching version in Redis failed", "address", addr, "err", err)
+	}
+}
+
+// restoreVersions exports the versions cached in Redis before the first
+// refresh, so that they are available at once. Cached targets that are not
+// configured are removed again by setTargets.
+func (s *Scraper) restoreVersions(ctx context.Context) {
+	if !s.redisUp {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*redisTimeout)
+	defer cancel()
+	var keys []string
+	iter := s.redisClient.Scan(ctx, 0, redisKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		slog.Warn("reading cached versions from Redis failed", "err", err)
+		return
+	}
+	if len(keys) == 0 {
+		return
+	}
+	values, err := s.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		slog.Warn("reading cached versions from Redis failed", "err", err)
+		return
+	}
+
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+	for i, key := range keys {
+		version, ok := values[i].(string)
+		if !ok || version == "" {
+			continue // expired since the scan
+		}
+		addr := strings.TrimPrefix(key, redisKeyPrefix)
+		st := s.stateFor(addr)
+		st.version, st.source = version, "redis"
+		s.exportVersion(addr, version, "redis")
+		slog.Info("restored cached version", "address", addr, "version", version)
+	}
+}
@@ -0,0 +1,343 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "go.temporal.io/api/workflowservice/v1"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testAddr is the target address of the Scrapers of newTestScraper. It is
+// an IP address, so no DNS lookup is made for it.
+const testAddr = "127.0.0.1:7233"
+
+// fakeFrontend is an in-memory Temporal frontend whose GetSystemInfo and
+// GetClusterInfo answers can be changed between refreshes.
+type fakeFrontend struct {
+	v1.UnimplementedWorkflowServiceServer
+
+	mu          sync.Mutex
+	systemInfo  func(context.Context) (*v1.GetSystemInfoResponse, error)
+	clusterInfo func(context.Context) (*v1.GetClusterInfoResponse, error)
+}
+
+// setVersion makes f report version from GetSystemInfo and nothing from
+// GetClusterInfo but the cluster name.
+func (f *fakeFrontend) setVersion(version string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.systemInfo = func(context.Context) (*v1.GetSystemInfoResponse, error) {
+		return &v1.GetSystemInfoResponse{ServerVersion: version}, nil
+	}
+	f.clusterInfo = func(context.Context) (*v1.GetClusterInfoResponse, error) {
+		return &v1.GetClusterInfoResponse{ClusterName: "test"}, nil
+	}
+}
+
+func (f *fakeFrontend) GetSystemInfo(ctx context.Context, _ *v1.GetSystemInfoRequest) (*v1.GetSystemInfoResponse, error) {
+	f.mu.Lock()
+	fn := f.systemInfo
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, status.Error(codes.Unimplemented, "GetSystemInfo")
+	}
+	return fn(ctx)
+}
+
+func (f *fakeFrontend) GetClusterInfo(ctx context.Context, _ *v1.GetClusterInfoRequest) (*v1.GetClusterInfoResponse, error) {
+	f.mu.Lock()
+	fn := f.clusterInfo
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, status.Error(codes.Unimplemented, "GetClusterInfo")
+	}
+	return fn(ctx)
+}
+
+// newTestScraper serves f over bufconn and returns a Scraper, configured
+// by opts, whose only target testAddr reaches it, and the function that
+// stops both. Tests defer it after goleak.VerifyNone, so that it runs
+// first.
+func newTestScraper(t *testing.T, f *fakeFrontend, opts ...Option) (*Scraper, func()) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	v1.RegisterWorkflowServiceServer(srv, f)
+	go srv.Serve(lis)
+
+	s, err := New(append([]Option{WithAddress(testAddr), WithMaxRetries(0)}, opts...)...)
+	if err != nil {
+		srv.Stop()
+		t.Fatalf("New: %v", err)
+	}
+	s.dialer = func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }
+	return s, func() {
+		s.Stop()
+		srv.Stop()
+	}
+}
+
+// refreshOnce refreshes the target of s once and returns its result.
+func refreshOnce(t *testing.T, s *Scraper) ScrapeResult {
+	t.Helper()
+	results, err := s.RefreshOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RefreshOnce: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("RefreshOnce returned %d results, want 1", len(results))
+	}
+	return results[0]
+}
+
+func TestRefreshExportsVersion(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	tests := []struct {
+		name        string
+		systemInfo  func(context.Context) (*v1.GetSystemInfoResponse, error)
+		clusterInfo func(context.Context) (*v1.GetClusterInfoResponse, error)
+		wantVersion string
+		wantSource  string
+	}{
+		{
+			name: "system info",
+			systemInfo: func(context.Context) (*v1.GetSystemInfoResponse, error) {
+				return &v1.GetSystemInfoResponse{ServerVersion: "1.23.0"}, nil
+			},
+			clusterInfo: func(context.Context) (*v1.GetClusterInfoResponse, error) {
+				return &v1.GetClusterInfoResponse{ServerVersion: "1.22.0"}, nil
+			},
+			wantVersion: "1.23.0",
+			wantSource:  "system_info",
+		},
+		{
+			name: "empty server version falls back to cluster info",
+			systemInfo: func(context.Context) (*v1.GetSystemInfoResponse, error) {
+				return &v1.GetSystemInfoResponse{Capabilities: &v1.GetSystemInfoResponse_Capabilities{SdkMetadata: true}}, nil
+			},
+			clusterInfo: func(context.Context) (*v1.GetClusterInfoResponse, error) {
+				return &v1.GetClusterInfoResponse{ServerVersion: "1.22.4"}, nil
+			},
+			wantVersion: "1.22.4",
+			wantSource:  "cluster_info",
+		},
+		{
+			name: "failed system info falls back to cluster info",
+			systemInfo: func(context.Context) (*v1.GetSystemInfoResponse, error) {
+				return nil, status.Error(codes.Internal, "boom")
+			},
+			clusterInfo: func(context.Context) (*v1.GetClusterInfoResponse, error) {
+				return &v1.GetClusterInfoResponse{ServerVersion: "1.24.1"}, nil
+			},
+			wantVersion: "1.24.1",
+			wantSource:  "cluster_info",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, stop := newTestScraper(t, &fakeFrontend{systemInfo: tt.systemInfo, clusterInfo: tt.clusterInfo})
+			defer stop()
+			res := refreshOnce(t, s)
+			if res.Err != nil {
+				t.Fatalf("refresh failed: %v", res.Err)
+			}
+			if res.Version != tt.wantVersion || res.Source != tt.wantSource {
+				t.Errorf("refresh found %q from %s, want %q from %s", res.Version, res.Source, tt.wantVersion, tt.wantSource)
+			}
+			if n := testutil.CollectAndCount(s.versionGauge); n != 1 {
+				t.Fatalf("%d version series, want 1", n)
+			}
+			if v := testutil.ToFloat64(s.versionGauge.WithLabelValues(testAddr, tt.wantVersion, "", "", "", tt.wantSource)); v != 1 {
+				t.Errorf("version series for %s from %s = %v, want 1", tt.wantVersion, tt.wantSource, v)
+			}
+			if v := testutil.ToFloat64(s.unknownGauge.WithLabelValues(testAddr)); v != 0 {
+				t.Errorf("unknown = %v, want 0", v)
+			}
+		})
+	}
+}
+
+func TestRefreshMarksUnknown(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	tests := []struct {
+		name        string
+		systemInfo  func(context.Context) (*v1.GetSystemInfoResponse, error)
+		clusterInfo func(context.Context) (*v1.GetClusterInfoResponse, error)
+	}{
+		{
+			name: "both RPCs fail",
+			systemInfo: func(context.Context) (*v1.GetSystemInfoResponse, error) {
+				return nil, status.Error(codes.Internal, "boom")
+			},
+			clusterInfo: func(context.Context) (*v1.GetClusterInfoResponse, error) {
+				return nil, status.Error(codes.PermissionDenied, "denied")
+			},
+		},
+		{
+			name: "unimplemented system info and no version in cluster info",
+			clusterInfo: func(context.Context) (*v1.GetClusterInfoResponse, error) {
+				return &v1.GetClusterInfoResponse{ClusterName: "test"}, nil
+			},
+		},
+		{
+			name: "no version in either response",
+			systemInfo: func(context.Context) (*v1.GetSystemInfoResponse, error) {
+				return &v1.GetSystemInfoResponse{}, nil
+			},
+			clusterInfo: func(context.Context) (*v1.GetClusterInfoResponse, error) {
+				return &v1.GetClusterInfoResponse{}, nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, stop := newTestScraper(t, &fakeFrontend{systemInfo: tt.systemInfo, clusterInfo: tt.clusterInfo})
+			defer stop()
+			res := refreshOnce(t, s)
+			if res.Version != "" || res.ErrorType != "no_version" {
+				t.Errorf("refresh found %q with error type %q, want none with no_version", res.Version, res.ErrorType)
+			}
+			if n := testutil.CollectAndCount(s.versionGauge); n != 0 {
+				t.Errorf("%d version series, want 0", n)
+			}
+			if v := testutil.ToFloat64(s.unknownGauge.WithLabelValues(testAddr)); v != 1 {
+				t.Errorf("unknown = %v, want 1", v)
+			}
+		})
+	}
+}
+
+func TestRefreshClearsUnknown(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	f := &fakeFrontend{
+		systemInfo: func(context.Context) (*v1.GetSystemInfoResponse, error) {
+			return nil, status.Error(codes.Internal, "boom")
+		},
+	}
+	s, stop := newTestScraper(t, f)
+	defer stop()
+	refreshOnce(t, s)
+	if v := testutil.ToFloat64(s.unknownGauge.WithLabelValues(testAddr)); v != 1 {
+		t.Fatalf("unknown after a failed refresh = %v, want 1", v)
+	}
+
+	f.setVersion("1.23.0")
+	refreshOnce(t, s)
+	if v := testutil.ToFloat64(s.unknownGauge.WithLabelValues(testAddr)); v != 0 {
+		t.Errorf("unknown after a successful refresh = %v, want 0", v)
+	}
+}
+
+func TestVersionInfoLabels(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"1.22.4", `temporal_server_version_info{address="127.0.0.1:7233",build="",prerelease="",revision="",source="system_info",version="1.22.4"} 1`},
+		{"1.22.4+deadbeef", `temporal_server_version_info{address="127.0.0.1:7233",build="",prerelease="",revision="deadbeef",source="system_info",version="1.22.4"} 1`},
+		{"1.23.0-rc.1", `temporal_server_version_info{address="127.0.0.1:7233",build="",prerelease="rc.1",revision="",source="system_info",version="1.23.0-rc.1"} 1`},
+		{"1.23.0-custom.3-g1a2b3c4", `temporal_server_version_info{address="127.0.0.1:7233",build="custom.3-g1a2b3c4",prerelease="",revision="",source="system_info",version="1.23.0"} 1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			f := &fakeFrontend{}
+			f.setVersion(tt.version)
+			s, stop := newTestScraper(t, f)
+			defer stop()
+			refreshOnce(t, s)
+			want := "# HELP temporal_server_version_info Server version detected on the target, carried in the version label; the value is always 1\n" +
+				"# TYPE temporal_server_version_info gauge\n" + tt.want + "\n"
+			if err := testutil.GatherAndCompare(s.Registry(), strings.NewReader(want), "temporal_server_version_info"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestVersionChangeLeavesOneSeries(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	f := &fakeFrontend{}
+	s, stop := newTestScraper(t, f)
+	defer stop()
+
+	for _, version := range []string{"1.22.4", "1.23.0", "1.22.4"} {
+		f.setVersion(version)
+		refreshOnce(t, s)
+		if n := testutil.CollectAndCount(s.versionGauge); n != 1 {
+			t.Fatalf("%d version series after upgrading to %s, want 1", n, version)
+		}
+		if v := testutil.ToFloat64(s.versionGauge.WithLabelValues(testAddr, version, "", "", "", "system_info")); v != 1 {
+			t.Errorf("version series for %s = %v, want 1", version, v)
+		}
+	}
+	if v := testutil.ToFloat64(s.versionChanges.WithLabelValues(testAddr)); v != 2 {
+		t.Errorf("version changes = %v, want 2", v)
+	}
+
+	// A switch of source replaces the series too.
+	f.mu.Lock()
+	f.systemInfo = nil
+	f.clusterInfo = func(context.Context) (*v1.GetClusterInfoResponse, error) {
+		return &v1.GetClusterInfoResponse{ServerVersion: "1.22.4"}, nil
+	}
+	f.mu.Unlock()
+	refreshOnce(t, s)
+	if n := testutil.CollectAndCount(s.versionGauge); n != 1 {
+		t.Fatalf("%d version series after switching to GetClusterInfo, want 1", n)
+	}
+	if v := testutil.ToFloat64(s.versionGauge.WithLabelValues(testAddr, "1.22.4", "", "", "", "cluster_info")); v != 1 {
+		t.Errorf("version series from cluster_info = %v, want 1", v)
+	}
+}
+
+func TestShardCountFollowsClusterInfo(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	f := &fakeFrontend{}
+	f.setVersion("1.23.0")
+	f.clusterInfo = func(context.Context) (*v1.GetClusterInfoResponse, error) {
+		return &v1.GetClusterInfoResponse{HistoryShardCount: 512}, nil
+	}
+	// The dial fails at the end of the test, after the dial timeout.
+	s, stop := newTestScraper(t, f, WithTimeouts(100*time.Millisecond, 5*time.Second))
+	defer stop()
+	refreshOnce(t, s)
+	if v := testutil.ToFloat64(s.shardCountGauge.WithLabelValues(testAddr)); v != 512 {
+		t.Fatalf("history shards = %v, want 512", v)
+	}
+
+	f.mu.Lock()
+	f.clusterInfo = func(context.Context) (*v1.GetClusterInfoResponse, error) {
+		return nil, status.Error(codes.PermissionDenied, "denied")
+	}
+	f.mu.Unlock()
+	refreshOnce(t, s)
+	if n := testutil.CollectAndCount(s.shardCountGauge); n != 0 {
+		t.Errorf("%d history shard series after GetClusterInfo failed, want 0", n)
+	}
+
+	f.mu.Lock()
+	f.clusterInfo = func(context.Context) (*v1.GetClusterInfoResponse, error) {
+		return &v1.GetClusterInfoResponse{HistoryShardCount: 512}, nil
+	}
+	f.mu.Unlock()
+	refreshOnce(t, s)
+	s.dialer = func(context.Context, string) (net.Conn, error) { return nil, errors.New("refused") }
+	if res := refreshOnce(t, s); res.ErrorType != "dial" {
+		t.Fatalf("refresh with a failing dialer: %+v, want a dial error", res)
+	}
+	if n := testutil.CollectAndCount(s.shardCountGauge); n != 0 {
+		t.Errorf("%d history shard series after a failed dial, want 0", n)
+	}
+}
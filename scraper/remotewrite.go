@@ -0,0 +1,152 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// remoteWriteMetrics are the series of remote write.
+type remoteWriteMetrics struct {
+	remoteWriteBytes  prometheus.Counter
+	remoteWriteErrors prometheus.Counter
+}
+
+func newRemoteWriteMetrics() remoteWriteMetrics {
+	return remoteWriteMetrics{
+		remoteWriteBytes: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "exporter_remote_write_bytes_total",
+				Help: "Compressed bytes successfully sent to --remote-write-url",
+			},
+		),
+		remoteWriteErrors: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "exporter_remote_write_errors_total",
+				Help: "Number of failed remote write pushes",
+			},
+		),
+	}
+}
+
+// runRemoteWrite pushes every metric of g to --remote-write-url each
+// --scrape-interval until ctx is cancelled.
+func (s *Scraper) runRemoteWrite(ctx context.Context, g prometheus.Gatherer) {
+	client := &http.Client{Timeout: s.cfg.RemoteWriteTimeout}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.cfg.ScrapeInterval):
+		}
+		if err := s.pushRemoteWrite(ctx, client, g); err != nil {
+			s.remoteWriteErrors.Inc()
+			slog.Error("remote write failed", "url", s.cfg.RemoteWriteURL, "err", err)
+		}
+	}
+}
+
+func (s *Scraper) pushRemoteWrite(ctx context.Context, client *http.Client, g prometheus.Gatherer) error {
+	families, err := g.Gather()
+	if err != nil {
+		return fmt.Errorf("gathering metrics: %w", err)
+	}
+	req := &prompb.WriteRequest{Timeseries: toTimeSeries(families, time.Now().UnixMilli())}
+	raw, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+	body := snappy.Encode(nil, raw)
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.RemoteWriteTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.RemoteWriteURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range s.cfg.RemoteWriteHeaders {
+		httpReq.Header[name] = values
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	httpReq.Header.Set("Content-Encoding", "snappy")
+	httpReq.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	httpReq.Header.Set("User-Agent", userAgent())
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("remote write returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	s.remoteWriteBytes.Add(float64(len(body)))
+	return nil
+}
+
+// toTimeSeries converts gathered metric families into remote write series,
+// one sample each at ts. Histograms and summaries are split into their
+// classic _bucket, _sum and _count series.
+func toTimeSeries(families []*dto.MetricFamily, ts int64) []prompb.TimeSeries {
+	var series []prompb.TimeSeries
+	add := func(name string, m *dto.Metric, value float64, extra ...prompb.Label) {
+		labels := make([]prompb.Label, 0, len(m.GetLabel())+len(extra)+1)
+		labels = append(labels, prompb.Label{Name: "__name__", Value: name})
+		for _, lp := range m.GetLabel() {
+			labels = append(labels, prompb.Label{Name: lp.GetName(), Value: lp.GetValue()})
+		}
+		labels = append(labels, extra...)
+		sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+		series = append(series, prompb.TimeSeries{
+			Labels:  labels,
+			Samples: []prompb.Sample{{Value: value, Timestamp: ts}},
+		})
+	}
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, m, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, m, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, m, m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					add(name, m, q.GetValue(), prompb.Label{Name: "quantile", Value: formatFloat(q.GetQuantile())})
+				}
+				add(name+"_sum", m, s.GetSampleSum())
+				add(name+"_count", m, float64(s.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					add(name+"_bucket", m, float64(b.GetCumulativeCount()), prompb.Label{Name: "le", Value: formatFloat(b.GetUpperBound())})
+				}
+				add(name+"_bucket", m, float64(h.GetSampleCount()), prompb.Label{Name: "le", Value: "+Inf"})
+				add(name+"_sum", m, h.GetSampleSum())
+				add(name+"_count", m, float64(h.GetSampleCount()))
+			}
+		}
+	}
+	return series
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, +1) {
+		return "+Inf"
+	}
+	return fmt.Sprint(f)
+}
@@ -0,0 +1,60 @@
+package scraper
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	retryBackoff = 200 * time.Millisecond
+)
+
+// retryable reports whether an RPC failing with code may succeed if
+// repeated. Auth, argument and Unimplemented errors never will.
+func retryable(code codes.Code) bool {
+	switch code {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+// retryInterceptor retries RPCs to addr that fail transiently up to
+// --max-retries times with exponential backoff. Every failure is logged
+// with its gRPC status code.
+func (s *Scraper) retryInterceptor(addr string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		backoff := retryBackoff
+		for attempt := 0; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil {
+				return nil
+			}
+			code := status.Code(err)
+			retry := retryable(code) && attempt < s.cfg.MaxRetries
+			// Unimplemented is expected from old servers and handled by the
+			// caller, and a cancelled ctx means the target or the exporter is
+			// going away, so neither is worth a warning.
+			level := slog.LevelWarn
+			if code == codes.Unimplemented || ctx.Err() != nil {
+				level = slog.LevelDebug
+			}
+			slog.Log(ctx, level, "rpc failed", "address", addr, "method", shortMethod(method), "grpc_code", code.String(),
+				"attempt", attempt+1, "retry", retry, "err", err)
+			if !retry {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package scraper
+
+import (
+	"io"
+	"text/template"
+)
+
+const runbookBase = "https://github.com/ujala-singh/temporal-version-exporter/blob/main/README.md"
+
+var rulesTemplate = template.Must(template.New("rules").Parse(`groups:
+  - name: temporal-version-exporter
+    rules:
+      - alert: TemporalVersionUnknown
+        expr: {{.P}}_server_version_unknown == 1
+        for: 5m
+        labels:
+          severity: warning
+        annotations:
+          summary: Temporal version unknown at {{"{{"}} $labels.address {{"}}"}}
+          description: The exporter has not been able to determine the server version of {{"{{"}} $labels.address {{"}}"}} for 5 minutes.
+          runbook_url: {{.Runbook}}#temporalversionunknown
+      - alert: TemporalExporterDown
+        expr: time() - {{.P}}_exporter_last_successful_scrape_timestamp_seconds > 600
+        for: 5m
+        labels:
+          severity: warning
+        annotations:
+          summary: No successful refresh of {{"{{"}} $labels.address {{"}}"}} for over 10 minutes
+          description: The exporter's last successful refresh of {{"{{"}} $labels.address {{"}}"}} was {{"{{"}} $value | humanizeDuration {{"}}"}} ago.
+          runbook_url: {{.Runbook}}#temporalexporterdown
+      - alert: TemporalVersionMismatch
+        expr: count(count_values("release", floor(({{.P}}_server_version_number + 0.5) / 1000))) > 1
+        for: 1h
+        labels:
+          severity: info
+        annotations:
+          summary: Temporal clusters run different minor versions
+          description: '{{"{{"}} $value {{"}}"}} different major.minor releases are deployed across monitored clusters.'
+          runbook_url: {{.Runbook}}#temporalversionmismatch
+      - alert: TemporalVersionRollback
+        expr: increase({{.P}}_server_version_rollback_total[15m]) > 0
+        labels:
+          severity: critical
+        annotations:
+          summary: Temporal version rolled back at {{"{{"}} $labels.address {{"}}"}}
+          description: The server version of {{"{{"}} $labels.address {{"}}"}} changed to an older release.
+          runbook_url: {{.Runbook}}#temporalversionrollback
+`))
+
+// WriteRules renders the Prometheus alerting rules for metrics named with
+// prefix.
+func WriteRules(w io.Writer, prefix string) error {
+	if err := validateMetricPrefix(prefix); err != nil {
+		return err
+	}
+	return rulesTemplate.Execute(w, struct{ P, Runbook string }{prefix, runbookBase})
+}
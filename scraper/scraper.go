@@ -0,0 +1,672 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	v1 "go.temporal.io/api/workflowservice/v1"
+	"google.golang.org/grpc/connectivity"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Config is the configuration of a Scraper, built by applying Options to
+// the defaults.
+type Config struct {
+	// Address is a frontend host:port, or an SRV record name such as
+	// _temporal._tcp.example.com whose targets are all monitored.
+	Address string
+	// KubernetesSelector, if set, replaces Address: the frontends of the
+	// Services matching this label selector are monitored, found with
+	// the kubeconfig at Kubeconfig or, if it is empty, the in-cluster
+	// service account.
+	KubernetesSelector string
+	Kubeconfig         string
+	// DNSRefreshInterval is how often an SRV Address is re-resolved.
+	DNSRefreshInterval time.Duration
+
+	// TLS enables TLS, verified against the CA certificate in TLSCAFile or
+	// the system roots, and presenting the client certificate in
+	// TLSCertFile and TLSKeyFile if set. TLSSet records that TLS was
+	// chosen explicitly, which stops it being enabled automatically for
+	// Temporal Cloud addresses. TLSInsecureSkipVerify skips the
+	// verification of the frontend's certificate.
+	TLS                                bool
+	TLSSet                             bool
+	TLSCAFile, TLSCertFile, TLSKeyFile string
+	TLSInsecureSkipVerify              bool
+	// APIKey is sent as a bearer token.
+	APIKey string
+
+	// DialTimeout bounds establishing a connection, including name
+	// resolution, and RequestTimeout each RPC attempt.
+	DialTimeout, RequestTimeout time.Duration
+	// MaxRetries is the number of retries per RPC for transient errors.
+	MaxRetries int
+	// Interceptors names the gRPC client interceptors, outermost first:
+	// retry, logging and metrics.
+	Interceptors []string
+
+	// ScrapeInterval is the time between refreshes of a target that is
+	// not backed off.
+	ScrapeInterval time.Duration
+	// AdaptiveThreshold is the number of consecutive failed refreshes
+	// after which a target's interval doubles with every further
+	// failure, up to AdaptiveMaxInterval or, if that is 0, ten times
+	// ScrapeInterval. 0 disables the backoff.
+	AdaptiveThreshold   int
+	AdaptiveMaxInterval time.Duration
+	// SystemInfoRecheck is how long GetSystemInfo is skipped on a target
+	// that answered Unimplemented.
+	SystemInfoRecheck time.Duration
+	// HealthProbe calls the gRPC health service of the frontend on every
+	// refresh.
+	HealthProbe bool
+	// SupportedClients limits the clients exported from GetClusterInfo;
+	// all are exported if it is empty.
+	SupportedClients []string
+
+	// MetricPrefix is joined to every metric name with "_".
+	MetricPrefix string
+	// ExtraLabels are added to every metric as constant labels.
+	ExtraLabels map[string]string
+
+	// StaleHandling is what happens to the last-known version while a
+	// target fails: keep, mark or drop, the last after StaleDropAfter
+	// consecutive failures.
+	StaleHandling  string
+	StaleDropAfter int
+	// VersionHistorySize is the number of versions remembered per target.
+	VersionHistorySize int
+
+	// MinVersion, ExpectedVersion and VersionConstraint are the version
+	// policies the targets are checked against; each is off when empty.
+	MinVersion, ExpectedVersion, VersionConstraint string
+	// TargetsFile, if set, is a YAML file of per-target settings that
+	// replace the global ones, re-read by ReloadTargets.
+	TargetsFile string
+	// SupportWindow is the number of most recent minor releases that
+	// count as supported.
+	SupportWindow int
+
+	// Offline disables every outbound call other than to Temporal: the
+	// latest release check, webhooks, PagerDuty, Redis and remote write.
+	Offline bool
+	// LatestCheckInterval is how often the latest Temporal release is
+	// looked up on GitHub; 0 disables the check.
+	LatestCheckInterval time.Duration
+
+	// WebhookURL receives a JSON event on every version change, signed
+	// with WebhookSecret if it is set.
+	WebhookURL     string
+	WebhookSecret  string
+	WebhookTimeout time.Duration
+	WebhookRetries int
+
+	// PagerDutyRoutingKey enables a PagerDuty alert for a target that
+	// has failed PagerDutyThreshold consecutive times.
+	PagerDutyRoutingKey string
+	PagerDutyThreshold  int
+
+	// RedisAddr, if set, is the Redis server that remembers the detected
+	// versions across restarts.
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+
+	// AuditLogPath, if set, is appended a JSON line for every version
+	// change, rotated at AuditLogMaxSizeMB.
+	AuditLogPath      string
+	AuditLogMaxSizeMB int
+
+	// TextfileOutput, if set, is rewritten with every metric after each
+	// refresh.
+	TextfileOutput string
+
+	// RemoteWriteURL, if set, is pushed every metric each ScrapeInterval,
+	// with RemoteWriteHeaders.
+	RemoteWriteURL     string
+	RemoteWriteHeaders http.Header
+	RemoteWriteTimeout time.Duration
+
+	// Extractor finds the version in the RPC responses. It defaults to
+	// DefaultVersionExtractor.
+	Extractor VersionExtractor
+	// Backend receives the results of refreshes. It defaults to the
+	// Scraper's Prometheus metrics.
+	Backend MetricBackend
+}
+
+// Option changes one setting of a Config.
+type Option func(*Config)
+
+// WithAddress monitors addr, a host:port or an SRV record name.
+func WithAddress(addr string) Option {
+	return func(c *Config) { c.Address = addr }
+}
+
+// WithKubernetesSelector monitors the frontends of the Kubernetes Services
+// matching selector instead of an address.
+func WithKubernetesSelector(selector string) Option {
+	return func(c *Config) { c.KubernetesSelector = selector }
+}
+
+// WithKubeconfig reads the Kubernetes client configuration from path
+// instead of using the in-cluster service account.
+func WithKubeconfig(path string) Option {
+	return func(c *Config) { c.Kubeconfig = path }
+}
+
+// WithDNSRefreshInterval sets how often an SRV address is re-resolved.
+func WithDNSRefreshInterval(d time.Duration) Option {
+	return func(c *Config) { c.DNSRefreshInterval = d }
+}
+
+// WithTLS connects over TLS. ca is a PEM CA certificate file to verify the
+// frontend with instead of the system roots; cert and key are a client
+// certificate to present. Any of them may be empty.
+func WithTLS(ca, cert, key string) Option {
+	return func(c *Config) {
+		c.TLS, c.TLSSet = true, true
+		c.TLSCAFile, c.TLSCertFile, c.TLSKeyFile = ca, cert, key
+	}
+}
+
+// WithoutTLS connects over plain TCP, Temporal Cloud addresses included.
+func WithoutTLS() Option {
+	return func(c *Config) { c.TLS, c.TLSSet = false, true }
+}
+
+// WithTLSInsecureSkipVerify skips the verification of the frontend's
+// certificate.
+func WithTLSInsecureSkipVerify() Option {
+	return func(c *Config) { c.TLSInsecureSkipVerify = true }
+}
+
+// WithAPIKey sends key as a bearer token on every RPC.
+func WithAPIKey(key string) Option {
+	return func(c *Config) { c.APIKey = key }
+}
+
+// WithTimeouts sets the timeouts of dialing a target and of each RPC
+// attempt.
+func WithTimeouts(dial, request time.Duration) Option {
+	return func(c *Config) { c.DialTimeout, c.RequestTimeout = dial, request }
+}
+
+// WithMaxRetries sets the number of retries per RPC for transient errors.
+func WithMaxRetries(n int) Option {
+	return func(c *Config) { c.MaxRetries = n }
+}
+
+// WithInterceptors replaces the gRPC client interceptors, named outermost
+// first. No names disables them all.
+func WithInterceptors(names ...string) Option {
+	return func(c *Config) { c.Interceptors = names }
+}
+
+// WithScrapeInterval sets the time between refreshes of a target.
+func WithScrapeInterval(d time.Duration) Option {
+	return func(c *Config) { c.ScrapeInterval = d }
+}
+
+// WithAdaptiveBackoff backs off a target's interval after threshold
+// consecutive failures, up to maxInterval. A threshold of 0 disables the
+// backoff; a maxInterval of 0 means ten times the scrape interval.
+func WithAdaptiveBackoff(threshold int, maxInterval time.Duration) Option {
+	return func(c *Config) { c.AdaptiveThreshold, c.AdaptiveMaxInterval = threshold, maxInterval }
+}
+
+// WithSystemInfoRecheck sets how long GetSystemInfo is skipped on a target
+// that answered Unimplemented.
+func WithSystemInfoRecheck(d time.Duration) Option {
+	return func(c *Config) { c.SystemInfoRecheck = d }
+}
+
+// WithHealthProbe calls the gRPC health service of every target on each
+// refresh.
+func WithHealthProbe() Option {
+	return func(c *Config) { c.HealthProbe = true }
+}
+
+// WithSupportedClients exports only the named clients of GetClusterInfo.
+func WithSupportedClients(names ...string) Option {
+	return func(c *Config) { c.SupportedClients = names }
+}
+
+// WithExtraLabels adds labels to every metric as constant labels. Later
+// calls add to the labels of earlier ones.
+func WithExtraLabels(labels map[string]string) Option {
+	return func(c *Config) {
+		if c.ExtraLabels == nil {
+			c.ExtraLabels = map[string]string{}
+		}
+		maps.Copy(c.ExtraLabels, labels)
+	}
+}
+
+// WithMetricPrefix replaces the "temporal" prefix of the metric names.
+func WithMetricPrefix(prefix string) Option {
+	return func(c *Config) { c.MetricPrefix = prefix }
+}
+
+// WithStaleHandling sets what happens to the version of a failing target:
+// keep, mark or drop, the last after dropAfter consecutive failures.
+func WithStaleHandling(mode string, dropAfter int) Option {
+	return func(c *Config) { c.StaleHandling, c.StaleDropAfter = mode, dropAfter }
+}
+
+// WithVersionHistorySize sets the number of versions remembered per target.
+func WithVersionHistorySize(n int) Option {
+	return func(c *Config) { c.VersionHistorySize = n }
+}
+
+// WithVersionPolicy checks every target's version against a minimum, an
+// expected version and a semver range. Empty values are not checked.
+func WithVersionPolicy(minVersion, expected, constraint string) Option {
+	return func(c *Config) {
+		c.MinVersion, c.ExpectedVersion, c.VersionConstraint = minVersion, expected, constraint
+	}
+}
+
+// WithTargetsFile reads per-target settings, the expected version and the
+// version constraint, from the YAML file at path.
+func WithTargetsFile(path string) Option {
+	return func(c *Config) { c.TargetsFile = path }
+}
+
+// WithSupportWindow sets the number of most recent minor releases that
+// count as supported.
+func WithSupportWindow(minors int) Option {
+	return func(c *Config) { c.SupportWindow = minors }
+}
+
+// WithOffline disables every outbound call other than to Temporal.
+func WithOffline() Option {
+	return func(c *Config) { c.Offline = true }
+}
+
+// WithLatestReleaseCheck looks up the latest Temporal release on GitHub
+// every interval.
+func WithLatestReleaseCheck(interval time.Duration) Option {
+	return func(c *Config) { c.LatestCheckInterval = interval }
+}
+
+// WithWebhook posts an event to url on every version change, signed with
+// secret unless it is empty, retrying a failed request up to retries
+// times.
+func WithWebhook(url, secret string, timeout time.Duration, retries int) Option {
+	return func(c *Config) {
+		c.WebhookURL, c.WebhookSecret, c.WebhookTimeout, c.WebhookRetries = url, secret, timeout, retries
+	}
+}
+
+// WithPagerDuty triggers a PagerDuty alert through routingKey once a
+// target has failed threshold consecutive times.
+func WithPagerDuty(routingKey string, threshold int) Option {
+	return func(c *Config) { c.PagerDutyRoutingKey, c.PagerDutyThreshold = routingKey, threshold }
+}
+
+// WithRedis remembers the detected versions across restarts in Redis.
+func WithRedis(addr, password string, db int) Option {
+	return func(c *Config) { c.RedisAddr, c.RedisPassword, c.RedisDB = addr, password, db }
+}
+
+// WithAuditLog appends a JSON line to path for every version change,
+// rotating it at maxSizeMB.
+func WithAuditLog(path string, maxSizeMB int) Option {
+	return func(c *Config) { c.AuditLogPath, c.AuditLogMaxSizeMB = path, maxSizeMB }
+}
+
+// WithTextfile rewrites path with every metric after each refresh.
+func WithTextfile(path string) Option {
+	return func(c *Config) { c.TextfileOutput = path }
+}
+
+// WithRemoteWrite pushes every metric to url each scrape interval.
+func WithRemoteWrite(url string, headers http.Header, timeout time.Duration) Option {
+	return func(c *Config) {
+		c.RemoteWriteURL, c.RemoteWriteHeaders, c.RemoteWriteTimeout = url, headers, timeout
+	}
+}
+
+// WithVersionExtractor replaces DefaultVersionExtractor.
+func WithVersionExtractor(e VersionExtractor) Option {
+	return func(c *Config) { c.Extractor = e }
+}
+
+// WithMetricBackend reports refresh results to b instead of the Prometheus
+// metrics.
+func WithMetricBackend(b MetricBackend) Option {
+	return func(c *Config) { c.Backend = b }
+}
+
+// ScrapeResult is the outcome of one refresh of a target.
+type ScrapeResult struct {
+	Address string
+	// Version is the detected server version, empty if none was found.
+	Version string
+	// ClusterName is the name GetClusterInfo reported, if it succeeded.
+	ClusterName string
+	// Source is the RPC that supplied Version: system_info or
+	// cluster_info.
+	Source   string
+	Duration time.Duration
+	// Err is why no version was detected. ErrorType classifies it as
+	// exported in the error_type label: dial or no_version.
+	Err       error
+	ErrorType string
+
+	// The raw results the series are derived from.
+	connState        connectivity.State
+	health           healthResult
+	sysResp          *v1.GetSystemInfoResponse
+	clusResp         *v1.GetClusterInfoResponse
+	sysUnimplemented bool
+	skippedSysInfo   bool
+	extraction       string
+}
+
+// Scraper runs one refresh loop per monitored frontend and reports the
+// results to its MetricBackend.
+type Scraper struct {
+	cfg       Config
+	metrics   MetricBackend
+	extractor VersionExtractor
+	registry  *prometheus.Registry
+
+	// connections holds one long-lived *grpc.ClientConn per target,
+	// reused across refreshes.
+	connections sync.Map
+	// dialer, if set, replaces the network dialer of the connections;
+	// tests use it to reach in-memory servers.
+	dialer func(ctx context.Context, addr string) (net.Conn, error)
+
+	runnersMu sync.Mutex
+	runners   map[string]*runner
+	// stopped is set by Stop, after which discovery can no longer start
+	// refresh loops.
+	stopped bool
+
+	// stopLoops cancels the discovery and background loops started by
+	// Start.
+	stopLoops context.CancelFunc
+	// background is cancelled by Stop. It bounds the goroutines that
+	// refreshes start, such as the webhook and PagerDuty senders, and bg
+	// tracks them together with the loops of Start and the connection
+	// watchers.
+	background     context.Context
+	stopBackground context.CancelFunc
+	bg             sync.WaitGroup
+
+	// minSemver and expectedSemver are the parsed MinVersion and
+	// ExpectedVersion, and versionConstraint the parsed
+	// VersionConstraint, nil when unset.
+	minSemver, expectedSemver semVersion
+	versionConstraint         *versionRange
+	// targetPolicies are the entries of TargetsFile by address, guarded
+	// by metricsMu.
+	targetPolicies map[string]targetPolicy
+
+	// metricsMu guards the target series as a group, together with
+	// targetStates and latestRelease: refresh updates them under the write
+	// lock and collection happens under the read lock, so a scrape never
+	// sees up, unknown and version disagree.
+	metricsMu    sync.RWMutex
+	targetStates map[string]*targetState
+	// latestRelease is the latest release tag without its "v", empty
+	// until the first successful check.
+	latestRelease string
+
+	// pendingFirst counts running targets that have not completed their
+	// first refresh; ready is closed when it first drops to zero.
+	pendingFirst atomic.Int64
+	ready        chan struct{}
+	readyOnce    sync.Once
+	// lastRefresh is the Unix time in nanoseconds at which the latest
+	// refresh of any target completed, or the Scraper was created.
+	lastRefresh atomic.Int64
+	startTime   time.Time
+
+	// textfileKick is signalled after every refresh. Its buffer of one
+	// coalesces refreshes that complete while a write is in progress.
+	textfileKick chan struct{}
+	// redisClient is nil unless --redis-addr is set. redisUp records
+	// whether Redis answered at startup; cached versions are only
+	// restored if it did.
+	redisClient *redis.Client
+	redisUp     bool
+	// auditLog is nil unless --audit-log-path is set.
+	auditLog *lumberjack.Logger
+
+	// pagerDutyQueue serializes PagerDuty events so a resolve is never
+	// sent before the trigger it resolves.
+	pagerDutyQueue     chan pagerDutyEvent
+	pagerDutyStartOnce sync.Once
+
+	coreMetrics
+	connMetrics
+	adaptiveMetrics
+	authMetrics
+	capabilityMetrics
+	clusterMetrics
+	dnsMetrics
+	extractionMetrics
+	healthMetrics
+	httpMetrics
+	latestMetrics
+	pagerDutyMetrics
+	policyMetrics
+	rateLimitMetrics
+	remoteWriteMetrics
+	staleMetrics
+	textfileMetrics
+	tlsCertMetrics
+	versionAgeMetrics
+	webhookMetrics
+	webTLSMetrics
+}
+
+// New applies opts in order to the default Config, validates the result
+// and returns a Scraper whose metrics are registered on its own registry.
+// Nothing is registered on prometheus.DefaultRegisterer, so several
+// Scrapers can be created in one process.
+func New(opts ...Option) (*Scraper, error) {
+	cfg := Config{
+		DNSRefreshInterval: 60 * time.Second,
+		DialTimeout:        10 * time.Second,
+		RequestTimeout:     5 * time.Second,
+		MaxRetries:         3,
+		Interceptors:       []string{"retry", "metrics"},
+		ScrapeInterval:     30 * time.Second,
+		AdaptiveThreshold:  3,
+		SystemInfoRecheck:  time.Hour,
+		MetricPrefix:       "temporal",
+		StaleHandling:      "keep",
+		StaleDropAfter:     3,
+		VersionHistorySize: 20,
+		SupportWindow:      3,
+		WebhookTimeout:     5 * time.Second,
+		WebhookRetries:     3,
+		PagerDutyThreshold: 3,
+		AuditLogMaxSizeMB:  100,
+		RemoteWriteTimeout: 10 * time.Second,
+		Extractor:          DefaultVersionExtractor{},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.Address == "" && cfg.KubernetesSelector == "" {
+		return nil, errors.New("no Temporal address or Kubernetes selector configured")
+	}
+	if cfg.ScrapeInterval <= 0 {
+		return nil, fmt.Errorf("scrape interval must be positive, got %s", cfg.ScrapeInterval)
+	}
+	if cfg.AdaptiveMaxInterval != 0 && cfg.AdaptiveMaxInterval < cfg.ScrapeInterval {
+		return nil, fmt.Errorf("--adaptive-max-interval (%s) must not be shorter than the scrape interval (%s)", cfg.AdaptiveMaxInterval, cfg.ScrapeInterval)
+	}
+	for _, validate := range []func(Config) error{
+		validateAdaptiveBackoff, validateStaleHandling, validateVersionHistorySize, validateSupportWindow, validateInterceptors,
+	} {
+		if err := validate(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, errors.New("a TLS client certificate needs both a certificate and a key file")
+	}
+	for name := range cfg.ExtraLabels {
+		if err := ValidateExtraLabel(name); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Extractor == nil {
+		return nil, errors.New("version extractor must not be nil")
+	}
+	if cfg.KubernetesSelector == "" && !isSRVName(cfg.Address) {
+		addr, err := normalizeAddr(cfg.Address)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Address = addr
+		if _, err := resolveConnConfig(cfg.Address, cfg); err != nil {
+			return nil, fmt.Errorf("invalid connection settings: %w", err)
+		}
+	}
+
+	s := &Scraper{
+		cfg:                cfg,
+		extractor:          cfg.Extractor,
+		registry:           prometheus.NewRegistry(),
+		runners:            map[string]*runner{},
+		targetStates:       map[string]*targetState{},
+		pagerDutyQueue:     make(chan pagerDutyEvent, 100),
+		ready:              make(chan struct{}),
+		startTime:          time.Now(),
+		textfileKick:       make(chan struct{}, 1),
+		coreMetrics:        newCoreMetrics(),
+		connMetrics:        newConnMetrics(),
+		adaptiveMetrics:    newAdaptiveMetrics(),
+		authMetrics:        newAuthMetrics(),
+		capabilityMetrics:  newCapabilityMetrics(),
+		clusterMetrics:     newClusterMetrics(),
+		dnsMetrics:         newDNSMetrics(),
+		extractionMetrics:  newExtractionMetrics(),
+		healthMetrics:      newHealthMetrics(),
+		httpMetrics:        newHTTPMetrics(),
+		latestMetrics:      newLatestMetrics(),
+		pagerDutyMetrics:   newPagerDutyMetrics(),
+		policyMetrics:      newPolicyMetrics(),
+		rateLimitMetrics:   newRateLimitMetrics(),
+		remoteWriteMetrics: newRemoteWriteMetrics(),
+		staleMetrics:       newStaleMetrics(),
+		textfileMetrics:    newTextfileMetrics(),
+		tlsCertMetrics:     newTLSCertMetrics(),
+		versionAgeMetrics:  newVersionAgeMetrics(),
+		webhookMetrics:     newWebhookMetrics(),
+		webTLSMetrics:      newWebTLSMetrics(),
+	}
+	s.lastRefresh.Store(s.startTime.UnixNano())
+	s.background, s.stopBackground = context.WithCancel(context.Background())
+	if err := s.parsePolicyVersions(); err != nil {
+		return nil, err
+	}
+	if s.cfg.TargetsFile != "" {
+		policies, err := loadTargetsFile(s.cfg.TargetsFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --targets-file: %w", err)
+		}
+		s.targetPolicies = policies
+	}
+	if err := s.openAuditLog(); err != nil {
+		return nil, err
+	}
+	s.metrics = cfg.Backend
+	if s.metrics == nil {
+		s.metrics = prometheusBackend{s}
+	}
+	if err := s.RegisterMetrics(s.registry); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Registry returns the registry holding the Scraper's metrics.
+func (s *Scraper) Registry() *prometheus.Registry { return s.registry }
+
+// Start restores the versions cached in Redis, discovers the targets and
+// starts their refresh loops in the background, together with the
+// textfile output, the latest release check and remote write if they are
+// configured. Discovery and those loops run until ctx is cancelled or Stop
+// is called; the refresh loops run until Stop is called.
+func (s *Scraper) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	s.runnersMu.Lock()
+	s.stopLoops = cancel
+	s.runnersMu.Unlock()
+
+	if s.cfg.TextfileOutput != "" {
+		s.bg.Go(func() { s.runTextfile(ctx, s.Registry()) })
+	}
+	s.openRedis(ctx)
+	s.restoreVersions(ctx)
+	if s.cfg.LatestCheckInterval > 0 && !s.cfg.Offline {
+		s.bg.Go(func() { s.runLatestReleaseCheck(ctx) })
+	}
+	if s.cfg.RemoteWriteURL != "" && !s.cfg.Offline {
+		s.bg.Go(func() { s.runRemoteWrite(ctx, s.Registry()) })
+	}
+
+	switch {
+	case s.cfg.KubernetesSelector != "":
+		if err := runKubernetesDiscovery(ctx, s.cfg.KubernetesSelector, s.cfg.Kubeconfig, s.setTargets); err != nil {
+			cancel()
+			return fmt.Errorf("Kubernetes discovery failed: %w", err)
+		}
+	case isSRVName(s.cfg.Address):
+		s.bg.Go(func() { s.runSRVDiscovery(ctx, s.cfg.Address) })
+	default:
+		s.setTargets([]string{s.cfg.Address})
+	}
+	return nil
+}
+
+// Stop stops discovery and the refresh loops, waits for refreshes in
+// progress and closes the connections, then stops the background loops
+// and notifications and closes Redis and the audit log. The exported
+// series are kept. Stop is also needed after RefreshOnce.
+func (s *Scraper) Stop() {
+	s.runnersMu.Lock()
+	if s.stopLoops != nil {
+		s.stopLoops()
+	}
+	s.stopped = true
+	for _, r := range s.runners {
+		r.cancel()
+	}
+	for addr, r := range s.runners {
+		<-r.done
+		r.forced.Wait()
+		s.closeConn(addr)
+	}
+	s.runners = map[string]*runner{}
+	s.runnersMu.Unlock()
+
+	s.stopBackground()
+	s.bg.Wait()
+	if s.redisClient != nil {
+		s.redisClient.Close()
+	}
+	if s.auditLog != nil {
+		s.auditLog.Close()
+	}
+}
@@ -0,0 +1,84 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// waitReady fails the test if s does not become ready within a second.
+func waitReady(t *testing.T, s *Scraper) {
+	t.Helper()
+	select {
+	case <-s.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("Scraper not ready after 1s")
+	}
+}
+
+func TestStopEndsRefreshLoops(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	f := &fakeFrontend{}
+	f.setVersion("1.23.0")
+	s, stop := newTestScraper(t, f, WithScrapeInterval(10*time.Millisecond))
+	defer stop()
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	waitReady(t, s)
+}
+
+func TestStopEndsSRVDiscovery(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	s, err := New(
+		WithAddress("_temporal._tcp.example.invalid"),
+		WithTimeouts(100*time.Millisecond, time.Second),
+		WithDNSRefreshInterval(time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	s.Stop()
+}
+
+func TestStopEndsPagerDuty(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	s, err := New(WithAddress(testAddr), WithPagerDuty("routing-key", 3))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	// The sender is started by the first event; start it without one,
+	// so that nothing is posted to PagerDuty.
+	s.pagerDutyStartOnce.Do(func() { s.bg.Go(s.runPagerDuty) })
+	s.Stop()
+}
+
+func TestStopEndsWebhooks(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	received := make(chan struct{})
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The connection is only watched for closing once the body is
+		// read.
+		io.Copy(io.Discard, r.Body)
+		close(received)
+		<-r.Context().Done()
+	}))
+	defer hook.Close()
+
+	s, err := New(WithAddress(testAddr), WithWebhook(hook.URL, "", time.Minute, 0))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s.notifyVersionChange(testAddr, "test", "1.22.0", "1.23.0")
+	<-received
+	// Stop cancels the webhook in progress instead of waiting a minute.
+	s.Stop()
+}
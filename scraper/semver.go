@@ -0,0 +1,138 @@
+package scraper
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// prereleaseRE and buildRE match the pre-release and build parts of a
+// semantic version (https://semver.org), without their "-" and "+".
+const (
+	prereleaseRE = `(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*`
+	buildRE      = `[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*`
+)
+
+// semverRE matches a semantic version with an optional leading "v".
+var semverRE = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-(` + prereleaseRE + `))?(?:\+(` + buildRE + `))?$`)
+
+type semVersion struct {
+	major, minor, patch uint64
+	prerelease          string
+	build               string
+}
+
+// parseSemver parses s as a semantic version. It reports false for anything
+// that is not a full MAJOR.MINOR.PATCH version.
+func parseSemver(s string) (semVersion, bool) {
+	m := semverRE.FindStringSubmatch(s)
+	if m == nil {
+		return semVersion{}, false
+	}
+	var v semVersion
+	var err error
+	if v.major, err = strconv.ParseUint(m[1], 10, 64); err != nil {
+		return semVersion{}, false
+	}
+	if v.minor, err = strconv.ParseUint(m[2], 10, 64); err != nil {
+		return semVersion{}, false
+	}
+	if v.patch, err = strconv.ParseUint(m[3], 10, 64); err != nil {
+		return semVersion{}, false
+	}
+	v.prerelease, v.build = m[4], m[5]
+	return v, true
+}
+
+// number encodes v as major*1e6 + minor*1e3 + patch so versions can be
+// compared numerically. Pre-releases sort just below their release.
+func (v semVersion) number() float64 {
+	n := float64(v.major)*1e6 + float64(v.minor)*1e3 + float64(v.patch)
+	if v.prerelease != "" {
+		n -= 0.5
+	}
+	return n
+}
+
+// compare returns -1, 0 or +1 as v sorts before, equal to or after w under
+// semver precedence. Build metadata is ignored.
+func (v semVersion) compare(w semVersion) int {
+	if c := cmp.Compare(v.major, w.major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.minor, w.minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.patch, w.patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.prerelease, w.prerelease)
+}
+
+// comparePrerelease orders pre-release strings; a release (empty string)
+// sorts after all of its pre-releases.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = cmp.Compare(an, bn)
+		case aErr == nil:
+			c = -1 // numeric identifiers sort before alphanumeric ones
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(as[i], bs[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
+
+// gitDescribeRE matches the "-g<commit>" suffix that git describe appends,
+// which marks a pre-release part as a custom build rather than an upstream
+// pre-release.
+var gitDescribeRE = regexp.MustCompile(`(^|[.-])g[0-9a-f]{7,40}$`)
+
+// splitVersion splits a detected version into the labels of
+// temporal_server_version_info: version is the MAJOR.MINOR.PATCH core plus
+// any upstream pre-release, revision the build metadata after "+", and
+// build a git describe style pre-release of a custom build:
+//
+//	1.22.4                   -> 1.22.4, "", "", ""
+//	1.23.0-rc1               -> 1.23.0-rc1, rc1, "", ""
+//	1.22.4+deadbeef          -> 1.22.4, "", deadbeef, ""
+//	1.23.0-custom.3-g1a2b3c4 -> 1.23.0, "", "", custom.3-g1a2b3c4
+//
+// Versions that are not semver are returned unchanged with empty parts.
+func splitVersion(raw string) (version, prerelease, revision, build string) {
+	sv, ok := parseSemver(raw)
+	if !ok {
+		return raw, "", "", ""
+	}
+	version = fmt.Sprintf("%d.%d.%d", sv.major, sv.minor, sv.patch)
+	prerelease = sv.prerelease
+	if gitDescribeRE.MatchString(prerelease) {
+		build, prerelease = prerelease, ""
+	}
+	if prerelease != "" {
+		version += "-" + prerelease
+	}
+	return version, prerelease, sv.build, build
+}
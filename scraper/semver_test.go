@@ -0,0 +1,139 @@
+package scraper
+
+import (
+	"testing"
+
+	"go.uber.org/goleak"
+)
+
+func TestSplitVersion(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	tests := []struct {
+		raw                                  string
+		version, prerelease, revision, build string
+	}{
+		{"1.22.4", "1.22.4", "", "", ""},
+		{"v1.22.4", "1.22.4", "", "", ""},
+		{"1.23.0-rc1", "1.23.0-rc1", "rc1", "", ""},
+		{"1.23.0-rc.1", "1.23.0-rc.1", "rc.1", "", ""},
+		{"1.22.4+deadbeef", "1.22.4", "", "deadbeef", ""},
+		{"1.23.0-rc.1+build.5", "1.23.0-rc.1", "rc.1", "build.5", ""},
+		{"1.23.0-custom.3-g1a2b3c4", "1.23.0", "", "", "custom.3-g1a2b3c4"},
+		{"1.23.0-g1a2b3c4d5e6f", "1.23.0", "", "", "g1a2b3c4d5e6f"},
+		{"1.23.0-custom.3-g1a2b3c4+dirty", "1.23.0", "", "dirty", "custom.3-g1a2b3c4"},
+		// Too short for a commit: an upstream pre-release.
+		{"1.23.0-g1a2b", "1.23.0-g1a2b", "g1a2b", "", ""},
+		// Not semver: unchanged, without parts.
+		{"1.23", "1.23", "", "", ""},
+		{"unknown", "unknown", "", "", ""},
+		{"", "", "", "", ""},
+	}
+	for _, tt := range tests {
+		version, prerelease, revision, build := splitVersion(tt.raw)
+		if version != tt.version || prerelease != tt.prerelease || revision != tt.revision || build != tt.build {
+			t.Errorf("splitVersion(%q) = %q, %q, %q, %q, want %q, %q, %q, %q", tt.raw,
+				version, prerelease, revision, build, tt.version, tt.prerelease, tt.revision, tt.build)
+		}
+	}
+}
+
+func TestParseSemver(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	tests := []struct {
+		in   string
+		want semVersion
+		ok   bool
+	}{
+		{"1.23.0", semVersion{major: 1, minor: 23}, true},
+		{"v1.22.4", semVersion{major: 1, minor: 22, patch: 4}, true},
+		{"0.0.0", semVersion{}, true},
+		{"1.23.0-rc.1", semVersion{major: 1, minor: 23, prerelease: "rc.1"}, true},
+		{"1.22.4+deadbeef", semVersion{major: 1, minor: 22, patch: 4, build: "deadbeef"}, true},
+		{"1.23.0-rc.1+build.5", semVersion{major: 1, minor: 23, prerelease: "rc.1", build: "build.5"}, true},
+		// Versions that are not full semver.
+		{"1.23", semVersion{}, false},
+		{"2024.1", semVersion{}, false},
+		{"127.0.0.1", semVersion{}, false},
+		{"2024.01.01", semVersion{}, false},
+		{"1.23.0-01", semVersion{}, false},
+		{"1.23.0-", semVersion{}, false},
+		{"99999999999999999999.0.0", semVersion{}, false},
+		{"", semVersion{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSemver(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseSemver(%q) = %+v, %v, want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSemverNumber(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"1.23.0", 1023000},
+		{"1.22.4", 1022004},
+		{"1.22.4+deadbeef", 1022004},
+		{"1.23.0-rc.1", 1022999.5},
+		{"0.0.1", 1},
+		{"2.0.0", 2000000},
+	}
+	for _, tt := range tests {
+		v, ok := parseSemver(tt.in)
+		if !ok {
+			t.Fatalf("parseSemver(%q) failed", tt.in)
+		}
+		if got := v.number(); got != tt.want {
+			t.Errorf("number of %s = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSemverOrder(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	// In ascending semver precedence.
+	ordered := []string{
+		"0.9.999",
+		"1.0.0",
+		"1.9.0",
+		"1.10.0",
+		"1.22.4",
+		"1.22.10",
+		"1.23.0-alpha",
+		"1.23.0-alpha.1",
+		"1.23.0-alpha.beta",
+		"1.23.0-beta.2",
+		"1.23.0-beta.11",
+		"1.23.0-rc.1",
+		"1.23.0",
+		"1.23.1",
+		"1.999.999",
+		"2.0.0",
+	}
+	for i := 1; i < len(ordered); i++ {
+		a, _ := parseSemver(ordered[i-1])
+		b, ok := parseSemver(ordered[i])
+		if !ok {
+			t.Fatalf("parseSemver(%q) failed", ordered[i])
+		}
+		if c := a.compare(b); c != -1 {
+			t.Errorf("compare(%s, %s) = %d, want -1", ordered[i-1], ordered[i], c)
+		}
+		if c := b.compare(a); c != 1 {
+			t.Errorf("compare(%s, %s) = %d, want 1", ordered[i], ordered[i-1], c)
+		}
+		// Pre-releases of one release share a number.
+		if a.number() > b.number() || a.number() == b.number() && (a.prerelease == "" || b.prerelease == "") {
+			t.Errorf("number of %s = %v is not below number of %s = %v", ordered[i-1], a.number(), ordered[i], b.number())
+		}
+	}
+
+	a, _ := parseSemver("1.22.4+deadbeef")
+	b, _ := parseSemver("1.22.4+cafe")
+	if c := a.compare(b); c != 0 {
+		t.Errorf("compare ignoring build metadata = %d, want 0", c)
+	}
+}
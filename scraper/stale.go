@@ -0,0 +1,70 @@
+package scraper
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// staleMetrics are the series of stale handling.
+type staleMetrics struct {
+	staleGauge *prometheus.GaugeVec
+}
+
+func newStaleMetrics() staleMetrics {
+	return staleMetrics{
+		staleGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_stale",
+				Help: "Set to 1 while the exported version is a last-known value from a target that is currently failing, 0 otherwise. Only exported with --stale-handling=mark",
+			},
+			[]string{"address"},
+		),
+	}
+}
+
+func validateStaleHandling(c Config) error {
+	switch c.StaleHandling {
+	case "keep", "mark", "drop":
+	default:
+		return fmt.Errorf("--stale-handling must be keep, mark or drop, got %q", c.StaleHandling)
+	}
+	if c.StaleDropAfter < 1 {
+		return fmt.Errorf("--stale-drop-after must be at least 1, got %d", c.StaleDropAfter)
+	}
+	return nil
+}
+
+// staleFailure applies --stale-handling after a failed refresh. It must be
+// called with s.metricsMu held.
+func (s *Scraper) staleFailure(addr string, st *targetState) {
+	st.failures++
+	switch s.cfg.StaleHandling {
+	case "mark":
+		if st.version != "" {
+			s.staleGauge.WithLabelValues(addr).Set(1)
+		}
+	case "drop":
+		if st.failures >= s.cfg.StaleDropAfter {
+			s.deleteVersionSeries(addr)
+		}
+	}
+}
+
+// staleSuccess clears the stale marker after a successful refresh. It must be
+// called with s.metricsMu held.
+func (s *Scraper) staleSuccess(addr string, st *targetState) {
+	st.failures = 0
+	if s.cfg.StaleHandling == "mark" {
+		s.staleGauge.WithLabelValues(addr).Set(0)
+	}
+}
+
+// deleteVersionSeries removes every series derived from the detected
+// version of addr. It must be called with s.metricsMu held.
+func (s *Scraper) deleteVersionSeries(addr string) {
+	s.metrics.ClearVersion(addr)
+	for _, g := range []*prometheus.GaugeVec{s.majorGauge, s.minorGauge, s.patchGauge, s.numberGauge, s.versionAgeGauge, s.versionsBehindGauge} {
+		g.DeleteLabelValues(addr)
+	}
+}
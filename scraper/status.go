@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+)
+
+var statusTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta http-equiv="refresh" content="10">
+<title>Temporal Version Exporter status</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.OK { background: #c8f7c5; }
+.STALE { background: #fde8a6; }
+.UNKNOWN { background: #f7c5c5; }
+</style>
+</head>
+<body>
+<h1>Temporal Version Exporter status</h1>
+<p>Version {{.Version}} ({{.Revision}}), up {{.Uptime}}</p>
+<p>{{.Total}} target{{if ne .Total 1}}s{{end}}, {{.Healthy}} healthy, {{.Unknown}} unknown</p>
+<table>
+<tr><th>Target</th><th>Version</th><th>Status</th><th>Last error</th><th>Last scrape duration</th><th>Next scrape</th></tr>
+{{range .Targets}}<tr><td>{{.Address}}</td><td>{{or .Version "-"}}</td><td class="{{.Status}}">{{.Status}}</td><td>{{or .LastError "-"}}</td><td>{{.Duration}}</td><td>{{.Next}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type statusTarget struct {
+	Address, Version, Status, LastError, Duration, Next string
+}
+
+// targetStatus classifies a target: OK if its latest refresh detected a
+// version, STALE if it failed but an earlier version is still known, and
+// UNKNOWN otherwise. It must be called with the Scraper's metricsMu held.
+func targetStatus(st *targetState) string {
+	switch {
+	case st.version == "":
+		return "UNKNOWN"
+	case st.lastError != "" || !st.succeeded:
+		return "STALE"
+	}
+	return "OK"
+}
+
+// statusHandler serves an HTML overview of the targets for humans, built
+// from the same state as /targets. It reloads itself every 10 seconds.
+func (s *Scraper) statusHandler(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Version, Revision, Uptime string
+		Total, Healthy, Unknown   int
+		Targets                   []statusTarget
+	}{
+		Version:  buildVersion,
+		Revision: buildRevision,
+		Uptime:   time.Since(s.startTime).Round(time.Second).String(),
+	}
+	s.metricsMu.RLock()
+	for addr, st := range s.targetStates {
+		t := statusTarget{Address: addr, Version: st.version, Status: targetStatus(st), LastError: st.lastError,
+			Duration: "-", Next: "-"}
+		if st.lastDuration > 0 {
+			t.Duration = st.lastDuration.Round(time.Millisecond).String()
+		}
+		if !st.nextRefresh.IsZero() {
+			t.Next = st.nextRefresh.UTC().Format(time.RFC3339)
+		}
+		switch t.Status {
+		case "OK":
+			data.Healthy++
+		case "UNKNOWN":
+			data.Unknown++
+		}
+		data.Targets = append(data.Targets, t)
+	}
+	s.metricsMu.RUnlock()
+	data.Total = len(data.Targets)
+	sort.Slice(data.Targets, func(i, j int) bool { return data.Targets[i].Address < data.Targets[j].Address })
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusTemplate.Execute(w, data); err != nil {
+		slog.Error("writing status page failed", "err", err)
+	}
+}
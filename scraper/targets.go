@@ -0,0 +1,205 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// runner is the refresh loop of one target.
+type runner struct {
+	// ctx is cancelled when the target is removed or the Scraper stops,
+	// which interrupts a refresh in progress.
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	cfg    connConfig
+	// refreshed is set once the first refresh has completed.
+	refreshed atomic.Bool
+	// forced counts refreshes started outside the loop by refreshAll.
+	forced sync.WaitGroup
+}
+
+// normalizeTargets returns the normalized form of the discovered
+// addresses in their order, without duplicates. Invalid addresses are
+// logged and skipped.
+func normalizeTargets(discovered []string) []string {
+	addrs := make([]string, 0, len(discovered))
+	for _, addr := range discovered {
+		norm, err := normalizeAddr(addr)
+		if err != nil {
+			slog.Error("skipping target", "address", addr, "err", err)
+			continue
+		}
+		if !slices.Contains(addrs, norm) {
+			addrs = append(addrs, norm)
+		}
+	}
+	return addrs
+}
+
+// setTargets reconciles the running refresh loops with addrs: loops are
+// started for new addresses, in the given order, and stopped for addresses
+// that disappeared, whose metrics are then deleted. Addresses are
+// normalized first, so each target has one address label.
+func (s *Scraper) setTargets(discovered []string) {
+	s.runnersMu.Lock()
+	defer s.runnersMu.Unlock()
+	if s.stopped {
+		return
+	}
+
+	addrs := normalizeTargets(discovered)
+	want := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		want[addr] = true
+	}
+	for addr, r := range s.runners {
+		if want[addr] {
+			continue
+		}
+		r.cancel()
+		<-r.done
+		// A refresh still running would recreate the series and the
+		// connection of the removed target.
+		r.forced.Wait()
+		if !r.refreshed.Load() {
+			s.pendingFirst.Add(-1)
+		}
+		delete(s.runners, addr)
+		s.closeConn(addr)
+		s.forgetTarget(addr)
+		slog.Info("target removed", "address", addr)
+	}
+	type start struct {
+		addr string
+		cfg  connConfig
+	}
+	var starts []start
+	for _, addr := range addrs {
+		if _, ok := s.runners[addr]; ok {
+			continue
+		}
+		cfg, err := resolveConnConfig(addr, s.cfg)
+		if err != nil {
+			slog.Error("skipping target", "address", addr, "err", err)
+			continue
+		}
+		starts = append(starts, start{addr, cfg})
+	}
+	// Count all new targets before any of them can complete, so Ready
+	// waits for the whole set.
+	s.pendingFirst.Add(int64(len(starts)))
+	for _, t := range starts {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := &runner{ctx: ctx, cancel: cancel, done: make(chan struct{}), cfg: t.cfg}
+		s.runners[t.addr] = r
+		s.initTarget(t.addr)
+		slog.Info("target added", "address", t.addr)
+		go s.run(ctx, r, t.addr, t.cfg)
+	}
+	if s.pendingFirst.Load() == 0 {
+		s.markReady()
+	}
+	s.forgetUntracked()
+}
+
+// run is the refresh loop of addr.
+func (s *Scraper) run(ctx context.Context, r *runner, addr string, cfg connConfig) {
+	defer close(r.done)
+	var interval time.Duration
+	for {
+		s.record(ctx, s.refresh(ctx, addr, cfg), cfg)
+		if ctx.Err() != nil {
+			return
+		}
+		s.refreshDone(!r.refreshed.Swap(true))
+		s.kickTextfile()
+		interval = s.nextInterval(addr, interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// initTarget creates the series that must exist from the moment a target
+// is configured.
+func (s *Scraper) initTarget(addr string) {
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+	s.stateFor(addr)
+	s.upGauge.WithLabelValues(addr).Set(0)
+	s.unknownGauge.WithLabelValues(addr).Set(0)
+}
+
+// forgetTarget deletes every series and all state kept for addr.
+func (s *Scraper) forgetTarget(addr string) {
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+	for _, vec := range s.perTargetMetrics() {
+		vec.DeletePartialMatch(prometheus.Labels{"address": addr})
+	}
+	delete(s.targetStates, addr)
+}
+
+// forgetUntracked deletes the state of addresses that have no runner, such
+// as versions restored from Redis for targets that are no longer configured.
+// It must be called with s.runnersMu held.
+func (s *Scraper) forgetUntracked() {
+	s.metricsMu.RLock()
+	var stale []string
+	for addr := range s.targetStates {
+		if _, ok := s.runners[addr]; !ok {
+			stale = append(stale, addr)
+		}
+	}
+	s.metricsMu.RUnlock()
+	for _, addr := range stale {
+		s.forgetTarget(addr)
+	}
+}
+
+// targetInfo is the /targets representation of a target.
+type targetInfo struct {
+	Address     string            `json:"address"`
+	Version     string            `json:"version"`
+	ClusterName string            `json:"cluster_name"`
+	ClusterID   string            `json:"cluster_id"`
+	Failures    int               `json:"consecutive_failures"`
+	Failover    *failoverVersions `json:"failover,omitempty"`
+}
+
+// targetsHandler serves the known state of every target as JSON.
+func (s *Scraper) targetsHandler(w http.ResponseWriter, r *http.Request) {
+	s.metricsMu.RLock()
+	infos := make([]targetInfo, 0, len(s.targetStates))
+	for addr, st := range s.targetStates {
+		infos = append(infos, targetInfo{
+			Address:     addr,
+			Version:     st.version,
+			ClusterName: st.clusterName,
+			ClusterID:   st.clusterID,
+			Failures:    st.failures,
+			Failover:    st.failover,
+		})
+	}
+	s.metricsMu.RUnlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Address < infos[j].Address })
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(infos); err != nil {
+		slog.Error("writing /targets response failed", "err", err)
+	}
+}
@@ -0,0 +1,60 @@
+package scraper
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "go.temporal.io/api/workflowservice/v1"
+	"go.uber.org/goleak"
+)
+
+func TestSetTargetsEndsRemovedTarget(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	f := &fakeFrontend{}
+	// Every GetSystemInfo but the first, made by the refresh loop, waits
+	// for release.
+	var calls atomic.Int32
+	release := make(chan struct{})
+	f.systemInfo = func(context.Context) (*v1.GetSystemInfoResponse, error) {
+		if calls.Add(1) > 1 {
+			<-release
+		}
+		return &v1.GetSystemInfoResponse{ServerVersion: "1.23.0"}, nil
+	}
+	s, stop := newTestScraper(t, f)
+	defer stop()
+	s.setTargets([]string{testAddr})
+	waitCalls(t, &calls, 1)
+
+	// A refresh forced through /version?refresh=true, still running when
+	// the target is removed, must end before the target's series and
+	// connection are dropped, or it would bring them back.
+	forced := make(chan struct{})
+	go func() {
+		s.refreshAll(time.Minute)
+		close(forced)
+	}()
+	waitCalls(t, &calls, 2)
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	s.setTargets(nil)
+	<-forced
+	if n := testutil.CollectAndCount(s.versionGauge) + testutil.CollectAndCount(s.unknownGauge); n != 0 {
+		t.Errorf("removed target left %d series", n)
+	}
+	if _, ok := s.connections.Load(testAddr); ok {
+		t.Error("removed target still has a connection")
+	}
+}
+
+// waitCalls fails the test if calls does not reach n within a second.
+func waitCalls(t *testing.T, calls *atomic.Int32, n int32) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); calls.Load() < n; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d calls after 1s, want %d", calls.Load(), n)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package scraper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// TargetConfig is an entry of the targets file: settings of the target at
+// Address that replace the global ones. Empty fields keep the global
+// setting. The file does not add targets; an entry applies to its address
+// however the target was found.
+type TargetConfig struct {
+	Address string `yaml:"address"`
+	// ExpectedVersion replaces --expected-version.
+	ExpectedVersion string `yaml:"expected_version"`
+	// VersionConstraint replaces --version-constraint.
+	VersionConstraint string `yaml:"version_constraint"`
+}
+
+// targetsFile is the YAML document of --targets-file.
+type targetsFile struct {
+	Targets []TargetConfig `yaml:"targets"`
+}
+
+// targetPolicy is a validated TargetConfig.
+type targetPolicy struct {
+	expected       string
+	expectedSemver semVersion
+	constraint     string
+	versionRange   *versionRange
+}
+
+// loadTargetsFile reads and validates the targets file at path and returns
+// its entries keyed by normalized address.
+func loadTargetsFile(path string) (map[string]targetPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f targetsFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	policies := make(map[string]targetPolicy, len(f.Targets))
+	for i, t := range f.Targets {
+		if t.Address == "" {
+			return nil, fmt.Errorf("%s: target %d has no address", path, i+1)
+		}
+		addr, err := normalizeAddr(t.Address)
+		if err != nil {
+			return nil, fmt.Errorf("%s: target %d: %w", path, i+1, err)
+		}
+		if _, dup := policies[addr]; dup {
+			return nil, fmt.Errorf("%s: target %s is listed twice", path, addr)
+		}
+		p, err := parseTargetConfig(t)
+		if err != nil {
+			return nil, fmt.Errorf("%s: target %s: %w", path, addr, err)
+		}
+		policies[addr] = p
+	}
+	return policies, nil
+}
+
+func parseTargetConfig(t TargetConfig) (targetPolicy, error) {
+	p := targetPolicy{expected: t.ExpectedVersion, constraint: t.VersionConstraint}
+	if t.ExpectedVersion != "" {
+		sv, ok := parseSemver(t.ExpectedVersion)
+		if !ok {
+			return p, fmt.Errorf("expected_version must be a semver version such as 1.22.0, got %q", t.ExpectedVersion)
+		}
+		p.expectedSemver = sv
+	}
+	if t.VersionConstraint != "" {
+		r, err := parseVersionRange(t.VersionConstraint)
+		if err != nil {
+			return p, fmt.Errorf("invalid version_constraint %q: %w", t.VersionConstraint, err)
+		}
+		p.versionRange = r
+	}
+	return p, nil
+}
+
+// ReloadTargets re-reads --targets-file, if it is set. The previous entries
+// are kept if the file fails to load. The series follow the new entries on
+// each target's next refresh.
+func (s *Scraper) ReloadTargets() error {
+	if s.cfg.TargetsFile == "" {
+		return nil
+	}
+	policies, err := loadTargetsFile(s.cfg.TargetsFile)
+	if err != nil {
+		return err
+	}
+	s.metricsMu.Lock()
+	s.targetPolicies = policies
+	s.metricsMu.Unlock()
+	slog.Info("reloaded targets file", "targets", len(policies))
+	return nil
+}
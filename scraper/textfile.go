@@ -0,0 +1,87 @@
+package scraper
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// textfileMetrics are the series of the textfile output.
+type textfileMetrics struct {
+	textfileLastWrite   prometheus.Gauge
+	textfileWriteErrors prometheus.Counter
+}
+
+func newTextfileMetrics() textfileMetrics {
+	return textfileMetrics{
+		textfileLastWrite: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "exporter_textfile_last_write_timestamp_seconds",
+			Help: "Unix time of the last successful write of --textfile-output",
+		}),
+		textfileWriteErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "exporter_textfile_write_errors_total",
+			Help: "Number of failed writes of --textfile-output",
+		}),
+	}
+}
+
+// kickTextfile asks runTextfile for a new write, if --textfile-output is
+// set.
+func (s *Scraper) kickTextfile() {
+	if s.cfg.TextfileOutput == "" {
+		return
+	}
+	select {
+	case s.textfileKick <- struct{}{}:
+	default:
+	}
+}
+
+// runTextfile writes every metric of g, except those node_exporter exports
+// itself, to --textfile-output after each refresh. WriteToTextfile writes
+// a temporary file next to it and renames it, so node_exporter never reads
+// a partial file. A failed write is logged and counted, and retried after
+// the next refresh. It returns once ctx is cancelled.
+func (s *Scraper) runTextfile(ctx context.Context, g prometheus.Gatherer) {
+	g = withoutRuntimeMetrics(g)
+	var written float64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.textfileKick:
+		}
+		// The timestamp is set first so that the file carries its own
+		// write time, and reverted if the write fails.
+		now := float64(time.Now().UnixNano()) / 1e9
+		s.textfileLastWrite.Set(now)
+		if err := prometheus.WriteToTextfile(s.cfg.TextfileOutput, g); err != nil {
+			s.textfileLastWrite.Set(written)
+			s.textfileWriteErrors.Inc()
+			slog.Error("writing textfile failed", "path", s.cfg.TextfileOutput, "err", err)
+			continue
+		}
+		written = now
+	}
+}
+
+// withoutRuntimeMetrics drops the go_, process_ and promhttp_ families
+// from what g gathers. node_exporter exports the same names about itself
+// and fails its scrape when a textfile repeats them.
+func withoutRuntimeMetrics(g prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := g.Gather()
+		kept := families[:0]
+		for _, mf := range families {
+			name := mf.GetName()
+			if !strings.HasPrefix(name, "go_") && !strings.HasPrefix(name, "process_") && !strings.HasPrefix(name, "promhttp_") {
+				kept = append(kept, mf)
+			}
+		}
+		return kept, err
+	})
+}
@@ -0,0 +1,69 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// tlsCertMetrics are the series of the targets' TLS certificates.
+type tlsCertMetrics struct {
+	tlsExpiryGauge    *prometheus.GaugeVec
+	endpointInfoGauge *prometheus.GaugeVec
+}
+
+func newTLSCertMetrics() tlsCertMetrics {
+	return tlsCertMetrics{
+		tlsExpiryGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "frontend_tls_certificate_expiry_timestamp_seconds",
+				Help: "Unix time at which the leaf certificate presented by the frontend expires, labeled with its issuer's common name. Only exported for TLS connections; updated on every handshake",
+			},
+			[]string{"address", "issuer_cn"},
+		),
+		endpointInfoGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "exporter_endpoint_info",
+				Help: "Connection settings in use for the target, carried in the labels; the value is always 1. Exported once the target has been refreshed successfully",
+			},
+			[]string{"address", "tls_enabled", "tls_ca_cert_fingerprint", "cluster_name", "api_key_configured"},
+		),
+	}
+}
+
+// recordPeerCertificate returns a tls.Config VerifyConnection hook that
+// exports the expiry of addr's leaf certificate. It runs after the normal
+// verification, and also with --tls-insecure-skip-verify.
+func (s *Scraper) recordPeerCertificate(addr string) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return nil
+		}
+		leaf := cs.PeerCertificates[0]
+		// The root of the verified chain is the CA; without verification
+		// the last certificate presented is the best approximation.
+		ca := cs.PeerCertificates[len(cs.PeerCertificates)-1]
+		if len(cs.VerifiedChains) > 0 {
+			chain := cs.VerifiedChains[0]
+			ca = chain[len(chain)-1]
+		}
+		sum := sha256.Sum256(ca.Raw)
+		s.metricsMu.Lock()
+		defer s.metricsMu.Unlock()
+		s.stateFor(addr).caFingerprint = hex.EncodeToString(sum[:])
+		s.tlsExpiryGauge.DeletePartialMatch(prometheus.Labels{"address": addr})
+		s.tlsExpiryGauge.WithLabelValues(addr, leaf.Issuer.CommonName).Set(float64(leaf.NotAfter.Unix()))
+		return nil
+	}
+}
+
+// setEndpointInfo exports the connection settings of addr. It must be
+// called with s.metricsMu held.
+func (s *Scraper) setEndpointInfo(addr string, st *targetState, cfg connConfig) {
+	s.endpointInfoGauge.DeletePartialMatch(prometheus.Labels{"address": addr})
+	s.endpointInfoGauge.WithLabelValues(addr, strconv.FormatBool(cfg.tls), st.caFingerprint, st.clusterName,
+		strconv.FormatBool(cfg.apiKey != "")).Set(1)
+}
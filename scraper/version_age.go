@@ -0,0 +1,129 @@
+package scraper
+
+//go:generate go run gen_release_dates.go
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// versionAgeMetrics are the series of the version age and support window.
+type versionAgeMetrics struct {
+	versionAgeGauge   *prometheus.GaugeVec
+	versionAgeUnknown *prometheus.CounterVec
+	minorsBehindGauge *prometheus.GaugeVec
+	supportedGauge    *prometheus.GaugeVec
+}
+
+func newVersionAgeMetrics() versionAgeMetrics {
+	return versionAgeMetrics{
+		versionAgeGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_age_seconds",
+				Help: "Time since the detected release was published. Absent for versions missing from the embedded release table",
+			},
+			[]string{"address"},
+		),
+		versionAgeUnknown: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "server_version_age_unknown_total",
+				Help: "Number of refreshes whose version is missing from the embedded release table, e.g. pre-releases, forks or releases newer than the exporter",
+			},
+			[]string{"address"},
+		),
+		minorsBehindGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_minor_versions_behind_latest",
+				Help: "Minor releases between the detected version and the newest one in the embedded release table, 0 when up to date. Absent for unknown versions",
+			},
+			[]string{"address"},
+		),
+		supportedGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "server_version_supported",
+				Help: "1 if the detected version is within the --support-window-minors newest minor releases of the embedded release table, 0 otherwise. reason is supported, too_old or unknown_version",
+			},
+			[]string{"address", "reason"},
+		),
+	}
+}
+
+// newestRelease is the newest version in the release table.
+var newestRelease = sync.OnceValue(func() semVersion {
+	var newest semVersion
+	for v := range releaseDates {
+		if sv, ok := parseSemver(v); ok && sv.compare(newest) > 0 {
+			newest = sv
+		}
+	}
+	return newest
+})
+
+func validateSupportWindow(c Config) error {
+	if c.SupportWindow < 1 {
+		return fmt.Errorf("--support-window-minors must be at least 1, got %d", c.SupportWindow)
+	}
+	return nil
+}
+
+// setSupportWindow exports how far addr's version is behind the newest
+// minor release and whether it is still supported; an empty version means
+// it is unknown. Versions newer than the table are supported. It must be
+// called with s.metricsMu held.
+func (s *Scraper) setSupportWindow(addr, version string) {
+	s.supportedGauge.DeletePartialMatch(prometheus.Labels{"address": addr})
+	sv, ok := parseSemver(version)
+	if !ok {
+		s.minorsBehindGauge.DeleteLabelValues(addr)
+		s.supportedGauge.WithLabelValues(addr, "unknown_version").Set(0)
+		return
+	}
+	newest := newestRelease()
+	supported := sv.major > newest.major
+	if sv.major == newest.major {
+		behind := uint64(0)
+		if newest.minor > sv.minor {
+			behind = newest.minor - sv.minor
+		}
+		s.minorsBehindGauge.WithLabelValues(addr).Set(float64(behind))
+		supported = behind < uint64(s.cfg.SupportWindow)
+	} else {
+		s.minorsBehindGauge.DeleteLabelValues(addr)
+	}
+	if supported {
+		s.supportedGauge.WithLabelValues(addr, "supported").Set(1)
+	} else {
+		s.supportedGauge.WithLabelValues(addr, "too_old").Set(0)
+	}
+}
+
+// releaseDate returns the release date of version, ignoring a leading "v"
+// and any build metadata.
+func releaseDate(version string) (time.Time, bool) {
+	v := strings.TrimPrefix(version, "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	s, ok := releaseDates[v]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	return t, err == nil
+}
+
+// setVersionAge exports the age of addr's version. It must be called with
+// s.metricsMu held.
+func (s *Scraper) setVersionAge(addr, version string) {
+	released, ok := releaseDate(version)
+	if !ok {
+		s.versionAgeGauge.DeleteLabelValues(addr)
+		s.versionAgeUnknown.WithLabelValues(addr).Inc()
+		return
+	}
+	s.versionAgeGauge.WithLabelValues(addr).Set(time.Since(released).Seconds())
+}
@@ -0,0 +1,110 @@
+package scraper
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// versionAPIVersion is the schema version of the /version response. It is
+// incremented whenever a field is removed or changes meaning.
+const versionAPIVersion = 1
+
+// versionTarget is the /version representation of a target. Absent values
+// are null rather than omitted, so the shape never changes.
+type versionTarget struct {
+	Address string `json:"address"`
+	// Version is the last detected version, kept while the target fails.
+	Version *string `json:"version"`
+	// Source is the RPC that supplied Version: system_info, cluster_info,
+	// or redis for a version restored at startup.
+	Source *string `json:"source"`
+	// DetectedAt is when Version was last detected.
+	DetectedAt *string `json:"detected_at"`
+	// Error is the error_type of the latest refresh if it failed.
+	Error *string `json:"error"`
+}
+
+type versionResponse struct {
+	APIVersion int             `json:"api_version"`
+	Targets    []versionTarget `json:"targets"`
+}
+
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// versionHandler serves the detected version of every target as JSON from
+// memory. With ?refresh=true every target is refreshed first, waiting at
+// most --grpc-request-timeout.
+func (s *Scraper) versionHandler(w http.ResponseWriter, r *http.Request) {
+	if q := r.URL.Query().Get("refresh"); q != "" {
+		force, err := strconv.ParseBool(q)
+		if err != nil {
+			http.Error(w, "invalid refresh parameter", http.StatusBadRequest)
+			return
+		}
+		if force {
+			s.refreshAll(s.cfg.RequestTimeout)
+		}
+	}
+
+	res := versionResponse{APIVersion: versionAPIVersion, Targets: []versionTarget{}}
+	s.metricsMu.RLock()
+	for addr, st := range s.targetStates {
+		t := versionTarget{
+			Address: addr,
+			Version: nullable(st.version),
+			Source:  nullable(st.source),
+			Error:   nullable(st.lastError),
+		}
+		if !st.detectedAt.IsZero() {
+			t.DetectedAt = nullable(st.detectedAt.UTC().Format(time.RFC3339))
+		}
+		res.Targets = append(res.Targets, t)
+	}
+	s.metricsMu.RUnlock()
+	sort.Slice(res.Targets, func(i, j int) bool { return res.Targets[i].Address < res.Targets[j].Address })
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(res); err != nil {
+		slog.Error("writing /version response failed", "err", err)
+	}
+}
+
+// refreshAll refreshes every running target concurrently and returns when
+// all have finished or timeout has passed, whichever is first. Refreshes
+// still running then complete in the background.
+func (s *Scraper) refreshAll(timeout time.Duration) {
+	s.runnersMu.Lock()
+	var wg sync.WaitGroup
+	for addr, r := range s.runners {
+		wg.Add(1)
+		r.forced.Add(1)
+		go func() {
+			defer wg.Done()
+			defer r.forced.Done()
+			s.record(r.ctx, s.refresh(r.ctx, addr, r.cfg), r.cfg)
+		}()
+	}
+	s.runnersMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
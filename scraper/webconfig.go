@@ -0,0 +1,61 @@
+package scraper
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/exporter-toolkit/web"
+	"go.yaml.in/yaml/v3"
+)
+
+// validateWebConfig checks the --web.config.file and the certificates it
+// names, so that a bad file fails at startup rather than on the first
+// connection.
+func (srv *Server) validateWebConfig() error {
+	c := srv.cfg
+	if c.WebConfigFile == "" {
+		return nil
+	}
+	if c.TLSCertFile != "" || c.TLSKeyFile != "" || c.BasicAuthUsersFile != "" || c.TLSMinVersion != "" {
+		return errors.New("--web.config.file cannot be combined with --web-tls-cert-file, --web-tls-key-file, --server-tls-min-version or --web-basic-auth-users-file")
+	}
+	return web.Validate(c.WebConfigFile)
+}
+
+// serveWebConfig serves hs on l as configured by --web.config.file. The
+// file is re-read for every new connection.
+func (srv *Server) serveWebConfig(hs *http.Server, l net.Listener) error {
+	return web.Serve(l, hs, &web.FlagConfig{WebConfigFile: &srv.cfg.WebConfigFile}, slog.Default())
+}
+
+// webConfigTLS reports whether --web.config.file enables TLS. It must be
+// called after validateWebConfig.
+func (srv *Server) webConfigTLS() bool {
+	return WebConfigTLS(srv.cfg.WebConfigFile)
+}
+
+// WebConfigTLS reports whether the exporter-toolkit web configuration file
+// at path enables TLS. It is false for an empty path and for a file that
+// cannot be read.
+func WebConfigTLS(path string) bool {
+	if path == "" {
+		return false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var cfg struct {
+		TLSServerConfig struct {
+			Cert     string `yaml:"cert"`
+			CertFile string `yaml:"cert_file"`
+		} `yaml:"tls_server_config"`
+	}
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return false
+	}
+	return cfg.TLSServerConfig.Cert != "" || cfg.TLSServerConfig.CertFile != ""
+}
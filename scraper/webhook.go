@@ -0,0 +1,118 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// webhookMetrics are the series of the webhook notifications.
+type webhookMetrics struct {
+	webhookSends *prometheus.CounterVec
+}
+
+func newWebhookMetrics() webhookMetrics {
+	return webhookMetrics{
+		webhookSends: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "exporter_webhook_sends_total",
+				Help: "Number of version change webhooks sent, by outcome after retries",
+			},
+			[]string{"status"},
+		),
+	}
+}
+
+type webhookEvent struct {
+	Event       string `json:"event"`
+	Address     string `json:"address"`
+	ClusterName string `json:"cluster_name"`
+	OldVersion  string `json:"old_version"`
+	NewVersion  string `json:"new_version"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// notifyVersionChange posts a version_change event in the background if
+// --webhook-url is set and --offline is not.
+func (s *Scraper) notifyVersionChange(addr, clusterName, oldVersion, newVersion string) {
+	if s.cfg.WebhookURL == "" || s.cfg.Offline {
+		return
+	}
+	ev := webhookEvent{
+		Event:       "version_change",
+		Address:     addr,
+		ClusterName: clusterName,
+		OldVersion:  oldVersion,
+		NewVersion:  newVersion,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+	}
+	s.bg.Go(func() {
+		if err := s.sendWebhook(s.background, ev); err != nil {
+			s.webhookSends.WithLabelValues("failure").Inc()
+			slog.Error("webhook failed", "address", addr, "err", err)
+			return
+		}
+		s.webhookSends.WithLabelValues("success").Inc()
+	})
+}
+
+// sendWebhook posts ev, retrying failures until --webhook-retries is used
+// up or ctx is cancelled.
+func (s *Scraper) sendWebhook(ctx context.Context, ev webhookEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	var sig string
+	if s.cfg.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(s.cfg.WebhookSecret))
+		mac.Write(body)
+		sig = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		err = s.postWebhook(ctx, body, sig)
+		if err == nil || attempt >= s.cfg.WebhookRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (s *Scraper) postWebhook(ctx context.Context, body []byte, sig string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.WebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+	if sig != "" {
+		req.Header.Set("X-Temporal-Signature", sig)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package scraper
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// webTLSMetrics are the series of the HTTP server's TLS.
+type webTLSMetrics struct {
+	serverTLSEnabled prometheus.Gauge
+}
+
+func newWebTLSMetrics() webTLSMetrics {
+	return webTLSMetrics{
+		serverTLSEnabled: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "exporter_server_tls_enabled",
+			Help: "Set to 1 if the exporter's HTTP endpoints are served over TLS, 0 otherwise",
+		}),
+	}
+}
+
+var tlsVersions = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+// certReloader serves a key pair from disk, loading it again when either
+// file's modification time changes. A pair that fails to load is logged
+// and the previous one kept, so a rotation caught half-written heals on a
+// later handshake.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	certMod  time.Time
+	keyMod   time.Time
+	failedAt time.Time
+}
+
+// newCertReloader loads the pair once, failing if it cannot be used.
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load must be called with r.mu held, except from newCertReloader.
+func (r *certReloader) load() error {
+	certMod, err := modTime(r.certFile)
+	if err != nil {
+		return fmt.Errorf("loading web TLS key pair: %w", err)
+	}
+	keyMod, err := modTime(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading web TLS key pair: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading web TLS key pair: %w", err)
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certMod, keyMod
+	return nil
+}
+
+func modTime(path string) (time.Time, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	certMod, err1 := modTime(r.certFile)
+	keyMod, err2 := modTime(r.keyFile)
+	changed := err1 == nil && err2 == nil && (!certMod.Equal(r.certMod) || !keyMod.Equal(r.keyMod))
+	// Retry a failed pair at most once a second rather than per handshake.
+	if changed && time.Since(r.failedAt) > time.Second {
+		if err := r.load(); err != nil {
+			r.failedAt = time.Now()
+			slog.Error("reloading web TLS certificate failed; keeping the previous one", "err", err)
+		} else {
+			slog.Info("reloaded web TLS certificate", "cert_file", r.certFile)
+		}
+	}
+	return r.cert, nil
+}
+
+// webTLSConfig returns the TLS configuration of the HTTP server, or nil if
+// it serves plain HTTP.
+func (srv *Server) webTLSConfig() (*tls.Config, error) {
+	c := srv.cfg
+	minVersion := uint16(tls.VersionTLS12)
+	if c.TLSMinVersion != "" {
+		var ok bool
+		if minVersion, ok = tlsVersions[c.TLSMinVersion]; !ok {
+			return nil, fmt.Errorf("invalid --server-tls-min-version %q, want TLS10, TLS11, TLS12 or TLS13", c.TLSMinVersion)
+		}
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return nil, errors.New("--web-tls-cert-file and --web-tls-key-file must be set together")
+	}
+	if c.TLSCertFile == "" {
+		return nil, nil
+	}
+	r, err := newCertReloader(c.TLSCertFile, c.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{MinVersion: minVersion, GetCertificate: r.getCertificate}, nil
+}